@@ -0,0 +1,60 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// A ContractAddressDeriver is an optional interface that a [ContractBackend]
+// MAY implement to override the derivation of the address returned by
+// [DeployContract]. Backends for chains that register the
+// params.RulesHooks.ContractAddressOverride hook MUST implement it, typically
+// with params.Rules.CreateAddress, otherwise DeployContract returns an error.
+// Backends that don't, e.g. an ethclient.Client, can be wrapped in a type that
+// does.
+type ContractAddressDeriver interface {
+	CreateAddress(from common.Address, nonce uint64) common.Address
+}
+
+// errNoContractAddressDeriver is returned by [DeployContract] if the backend
+// isn't a [ContractAddressDeriver] but contract addresses may be overridden.
+var errNoContractAddressDeriver = errors.New("contract addresses may be overridden by params.RulesHooks but backend doesn't implement bind.ContractAddressDeriver")
+
+// checkContractAddressDeriver returns [errNoContractAddressDeriver] if
+// [createAddress] can't be relied upon to return the correct address for the
+// backend. It MUST be called before a deployment transaction is sent.
+func checkContractAddressDeriver(backend ContractBackend) error {
+	if _, ok := backend.(ContractAddressDeriver); ok || !params.MayOverrideContractAddresses() {
+		return nil
+	}
+	return errNoContractAddressDeriver
+}
+
+// createAddress returns the address of a contract deployed by a transaction
+// sent to the backend, deferring to the backend if it is a
+// [ContractAddressDeriver].
+func createAddress(backend ContractBackend, from common.Address, nonce uint64) common.Address {
+	if d, ok := backend.(ContractAddressDeriver); ok {
+		return d.CreateAddress(from, nonce)
+	}
+	return crypto.CreateAddress(from, nonce)
+}
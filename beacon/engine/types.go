@@ -74,6 +74,7 @@ type ExecutableData struct {
 	Withdrawals   []*types.Withdrawal `json:"withdrawals"`
 	BlobGasUsed   *uint64             `json:"blobGasUsed"`
 	ExcessBlobGas *uint64             `json:"excessBlobGas"`
+	BodyExtra     hexutil.Bytes       `json:"bodyExtra,omitempty"` // libevm: see types.Block.EncodeBodyExtra()
 }
 
 // JSON type overrides for executableData.
@@ -254,10 +255,16 @@ func ExecutableDataToBlock(params ExecutableData, versionedHashes []common.Hash,
 		BlobGasUsed:      params.BlobGasUsed,
 		ParentBeaconRoot: beaconRoot,
 	}
-	block := types.NewBlockWithHeader(header).WithBody(txs, nil /* uncles */).WithWithdrawals(params.Withdrawals)
+	block, err := types.NewBlockWithHeader(header).WithBody(txs, nil /* uncles */).WithWithdrawals(params.Withdrawals).WithEncodedBodyExtra(params.BodyExtra) // libevm: WithEncodedBodyExtra()
+	if err != nil {
+		return nil, fmt.Errorf("invalid bodyExtra: %v", err)
+	}
 	if block.Hash() != params.BlockHash {
 		return nil, fmt.Errorf("blockhash mismatch, want %x, got %x", params.BlockHash, block.Hash())
 	}
+	if err := types.VerifyBodyExtra(block.Header(), block.Body()); err != nil { // libevm
+		return nil, fmt.Errorf("invalid bodyExtra: %v", err)
+	}
 	return block, nil
 }
 
@@ -283,6 +290,7 @@ func BlockToExecutableData(block *types.Block, fees *big.Int, sidecars []*types.
 		BlobGasUsed:   block.BlobGasUsed(),
 		ExcessBlobGas: block.ExcessBlobGas(),
 	}
+	data.BodyExtra, _ = block.EncodeBodyExtra() // libevm
 	bundle := BlobsBundleV1{
 		Commitments: make([]hexutil.Bytes, 0),
 		Blobs:       make([]hexutil.Bytes, 0),
@@ -0,0 +1,101 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// tagBody is a [types.BlockBodyHooks] payload that appends a tag to the body
+// encoding; the tag MUST be equal to the header's Extra field.
+type tagBody struct {
+	types.NOOPBlockBodyHooks
+	Tag []byte
+}
+
+type tagBodyRLP struct {
+	Txs         []*types.Transaction
+	Uncles      []*types.Header
+	Withdrawals []*types.Withdrawal
+	Tag         []byte
+}
+
+func (b *tagBody) EncodeRLP(body *types.Body, w io.Writer) error {
+	return rlp.Encode(w, &tagBodyRLP{body.Transactions, body.Uncles, body.Withdrawals, b.Tag})
+}
+
+func (b *tagBody) DecodeRLP(body *types.Body, s *rlp.Stream) error {
+	var dec tagBodyRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	body.Transactions, body.Uncles, body.Withdrawals, b.Tag = dec.Txs, dec.Uncles, dec.Withdrawals, dec.Tag
+	return nil
+}
+
+func (b *tagBody) VerifyAgainstHeader(_ *types.Body, h *types.Header) error {
+	if !bytes.Equal(b.Tag, h.Extra) {
+		return errors.New("tag mismatch")
+	}
+	return nil
+}
+
+func TestExecutableDataBodyExtra(t *testing.T) {
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	payloads := types.RegisterExtras[types.NOOPHeaderHooks, *types.NOOPHeaderHooks, tagBody, *tagBody, struct{}]()
+
+	tag := []byte("libevm")
+	hdr := &types.Header{
+		Difficulty: new(big.Int),
+		Number:     big.NewInt(1),
+		BaseFee:    big.NewInt(1),
+		UncleHash:  types.EmptyUncleHash,
+		TxHash:     types.EmptyTxsHash,
+		Extra:      tag,
+	}
+	block := types.NewBlockWithHeader(hdr)
+	payloads.SetOnBlock(block, &tagBody{Tag: tag})
+
+	env := BlockToExecutableData(block, new(big.Int), nil)
+	buf, err := json.Marshal(env.ExecutionPayload)
+	require.NoError(t, err, "json.Marshal(ExecutableData)")
+	var data ExecutableData
+	require.NoError(t, json.Unmarshal(buf, &data), "json.Unmarshal(..., ExecutableData)")
+
+	got, err := ExecutableDataToBlock(data, nil, nil)
+	require.NoError(t, err, "ExecutableDataToBlock()")
+	assert.Equal(t, block.Hash(), got.Hash(), "block hash")
+	assert.Equal(t, tag, payloads.FromBlock(got).Tag, "body extra carried through ExecutableData")
+
+	mismatched := types.NewBlockWithHeader(hdr)
+	payloads.SetOnBlock(mismatched, &tagBody{Tag: []byte("other")})
+	data = *BlockToExecutableData(mismatched, new(big.Int), nil).ExecutionPayload
+	_, err = ExecutableDataToBlock(data, nil, nil)
+	assert.Error(t, err, "ExecutableDataToBlock() with body extra not committed to by header")
+}
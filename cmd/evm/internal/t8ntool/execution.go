@@ -30,7 +30,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
@@ -192,6 +191,12 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 		evm := vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vmConfig)
 		core.ProcessBeaconBlockRoot(*beaconRoot, evm, statedb)
 	}
+	// libevm: pre- and post-block hooks are run at the same points as in
+	// core.StateProcessor.Process().
+	header := pre.Env.header()
+	if err := core.ProcessPreBlockHooks(header, vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vmConfig)); err != nil {
+		return nil, nil, nil, NewError(ErrorEVM, fmt.Errorf("pre-block hooks: %v", err))
+	}
 
 	for i := 0; txIt.Next(); i++ {
 		tx, err := txIt.Tx()
@@ -274,7 +279,8 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 
 			// If the transaction created a contract, store the creation address in the receipt.
 			if msg.To == nil {
-				receipt.ContractAddress = crypto.CreateAddress(evm.TxContext.Origin, tx.Nonce())
+				rules := chainConfig.Rules(vmContext.BlockNumber, vmContext.Random != nil, vmContext.Time) // libevm
+				receipt.ContractAddress = rules.CreateAddress(evm.TxContext.Origin, tx.Nonce())            // libevm: overridable
 			}
 
 			// Set the receipt logs and create the bloom filter.
@@ -289,6 +295,12 @@ func (pre *Prestate) Apply(vmConfig vm.Config, chainConfig *params.ChainConfig,
 
 		txIndex++
 	}
+	// libevm: the hooks aren't transactions so MUST NOT be traced.
+	vmConfig.Tracer = nil
+	header.GasUsed = gasUsed
+	if err := core.ProcessPostBlockHooks(header, vm.NewEVM(vmContext, vm.TxContext{}, statedb, chainConfig, vmConfig)); err != nil {
+		return nil, nil, nil, NewError(ErrorEVM, fmt.Errorf("post-block hooks: %v", err))
+	}
 	statedb.IntermediateRoot(chainConfig.IsEIP158(vmContext.BlockNumber))
 	// Add mining reward? (-1 means rewards are disabled)
 	if miningReward >= 0 {
@@ -0,0 +1,45 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// header returns the fields of the environment that describe the block being
+// built, for use by the [core.Hooks], which are called with a [types.Header].
+// Fields that are only known once the block is complete, such as the state
+// root, are left empty.
+func (env *stEnv) header() *types.Header {
+	h := &types.Header{
+		Coinbase:         env.Coinbase,
+		Difficulty:       env.Difficulty,
+		Number:           new(big.Int).SetUint64(env.Number),
+		GasLimit:         env.GasLimit,
+		Time:             env.Timestamp,
+		BaseFee:          env.BaseFee,
+		ExcessBlobGas:    env.ExcessBlobGas,
+		ParentBeaconRoot: env.ParentBeaconBlockRoot,
+	}
+	if env.Random != nil {
+		h.MixDigest = common.BigToHash(env.Random)
+	}
+	return h
+}
@@ -79,6 +79,7 @@ Remove blockchain and state databases`,
 			dbExportCmd,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
+			dbUpgradePayloadsCmd, // libevm
 		},
 	}
 	dbInspectCmd = &cli.Command{
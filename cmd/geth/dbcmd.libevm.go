@@ -0,0 +1,53 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli/v2"
+)
+
+var dbUpgradePayloadsCmd = &cli.Command{
+	Action: dbUpgradePayloads,
+	Name:   "upgrade-payloads",
+	Usage:  "Rewrite stored chain configs with the latest versions of their extra payloads",
+	Flags:  flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+	Description: `This command rewrites all stored data that can carry extra payloads versioned
+with pseudo.RegisterVersions(), upgrading them to the latest registered version.
+It is only meaningful for binaries that register such payloads, and MUST be run
+before support for an earlier version is dropped. The node MUST NOT be running.`,
+}
+
+// dbUpgradePayloads runs [rawdb.UpgradeVersionedPayloads] on the chain
+// database.
+func dbUpgradePayloads(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	n, err := rawdb.UpgradeVersionedPayloads(db)
+	if err != nil {
+		return err
+	}
+	log.Info("Upgraded versioned payloads", "rewritten", n)
+	return nil
+}
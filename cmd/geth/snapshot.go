@@ -586,6 +586,9 @@ func dumpState(ctx *cli.Context) error {
 			CodeHash:    account.CodeHash,
 			AddressHash: accIt.Hash().Bytes(),
 		}
+		if account.TypeErasedExtra() != nil { // libevm: only if extras are registered
+			da.Extra = account.Extra
+		}
 		if !conf.SkipCode && !bytes.Equal(account.CodeHash, types.EmptyCodeHash.Bytes()) {
 			da.Code = rawdb.ReadCode(db, common.BytesToHash(account.CodeHash))
 		}
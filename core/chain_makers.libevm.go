@@ -0,0 +1,46 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import "github.com/ethereum/go-ethereum/core/vm"
+
+// processPreBlockHooks calls [ProcessPreBlockHooks] if it hasn't already been
+// called for the block. It is deferred until the first transaction is added,
+// or until the block is finalised if there are none, so that the coinbase
+// and any EIP-4788 beacon root are set before the hooks run, as they would be
+// by [StateProcessor.Process]. It panics on error.
+func (b *BlockGen) processPreBlockHooks() {
+	if b.preBlockHooksRun {
+		return
+	}
+	b.preBlockHooksRun = true
+	if err := ProcessPreBlockHooks(b.header, b.newSystemEVM()); err != nil {
+		panic(err)
+	}
+}
+
+// processPostBlockHooks calls [ProcessPostBlockHooks], panicking on error.
+func (b *BlockGen) processPostBlockHooks() {
+	if err := ProcessPostBlockHooks(b.header, b.newSystemEVM()); err != nil {
+		panic(err)
+	}
+}
+
+func (b *BlockGen) newSystemEVM() *vm.EVM {
+	blockContext := NewEVMBlockContext(b.header, b.cm, &b.header.Coinbase)
+	return vm.NewEVM(blockContext, vm.TxContext{}, b.statedb, b.cm.config, vm.Config{})
+}
@@ -140,6 +140,7 @@ func hashAlloc(ga *types.GenesisAlloc, isVerkle bool) (common.Hash, error) {
 		for key, value := range account.Storage {
 			statedb.SetState(addr, key, value)
 		}
+		setAllocExtra(statedb, addr, account) // libevm
 	}
 	return statedb.Commit(0, false)
 }
@@ -161,6 +162,7 @@ func flushAlloc(ga *types.GenesisAlloc, db ethdb.Database, triedb *triedb.Databa
 		for key, value := range account.Storage {
 			statedb.SetState(addr, key, value)
 		}
+		setAllocExtra(statedb, addr, account) // libevm
 	}
 	root, err := statedb.Commit(0, false)
 	if err != nil {
@@ -0,0 +1,85 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/libevm/ethtest"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+func TestGenesisAllocExtras(t *testing.T) {
+	type accountExtra struct {
+		Rent uint64 `json:"rent"`
+	}
+
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	payloads := types.RegisterExtras[
+		types.NOOPHeaderHooks, *types.NOOPHeaderHooks,
+		types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks,
+		accountExtra,
+	]()
+
+	rng := ethtest.NewPseudoRand(42)
+	withExtra := rng.Address()
+	withoutExtra := rng.Address()
+	extra := accountExtra{Rent: 314159}
+
+	acc := types.Account{Balance: big.NewInt(1), Nonce: 1}
+	payloads.SetOnGenesisAccount(&acc, extra)
+	in := &core.Genesis{
+		Config:     params.TestChainConfig,
+		Difficulty: big.NewInt(1),
+		Alloc: types.GenesisAlloc{
+			withExtra:    acc,
+			withoutExtra: {Balance: big.NewInt(1), Nonce: 1},
+		},
+	}
+
+	buf, err := json.Marshal(in)
+	require.NoErrorf(t, err, "json.Marshal(%T)", in)
+	require.Contains(t, string(buf), `"extra":{"rent":314159}`, "JSON-encoded genesis")
+
+	gen := new(core.Genesis)
+	require.NoErrorf(t, json.Unmarshal(buf, gen), "json.Unmarshal(..., %T)", gen)
+	assert.Equal(t, extra, payloads.FromGenesisAccount(ptrTo(gen.Alloc[withExtra])), "FromGenesisAccount() after JSON round trip")
+	assert.Zero(t, payloads.FromGenesisAccount(ptrTo(gen.Alloc[withoutExtra])), "FromGenesisAccount() of account without extra")
+
+	db := rawdb.NewMemoryDatabase()
+	tdb := triedb.NewDatabase(db, triedb.HashDefaults)
+	block := gen.MustCommit(db, tdb)
+	assert.Equal(t, gen.ToBlock().Root(), block.Root(), "state root of committed genesis block vs ToBlock()")
+
+	sdb, err := state.New(block.Root(), state.NewDatabaseWithNodeDB(db, tdb), nil)
+	require.NoError(t, err, "state.New([genesis root])")
+	assert.Equal(t, extra, state.GetExtra(sdb, payloads, withExtra), "state.GetExtra() of account with extra")
+	assert.Zero(t, state.GetExtra(sdb, payloads, withoutExtra), "state.GetExtra() of account without extra")
+}
+
+func ptrTo[T any](x T) *T { return &x }
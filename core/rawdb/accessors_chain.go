@@ -687,6 +687,15 @@ type receiptLogs struct {
 
 // DecodeRLP implements rlp.Decoder.
 func (r *receiptLogs) DecodeRLP(s *rlp.Stream) error {
+	if _, ok := new(types.Receipt).Hooks().(*types.NOOPReceiptHooks); !ok { // libevm
+		// Registered hooks may have modified the storage encoding.
+		var stored types.ReceiptForStorage
+		if err := s.Decode(&stored); err != nil {
+			return err
+		}
+		r.Logs = stored.Logs
+		return nil
+	}
 	var stored storedReceiptRLP
 	if err := s.Decode(&stored); err != nil {
 		return err
@@ -753,7 +762,7 @@ func ReadBlock(db ethdb.Reader, hash common.Hash, number uint64) *types.Block {
 	if body == nil {
 		return nil
 	}
-	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles).WithWithdrawals(body.Withdrawals)
+	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles).WithWithdrawals(body.Withdrawals).WithBodyExtra(body)
 }
 
 // WriteBlock serializes a block into the database, header and body separately.
@@ -843,7 +852,7 @@ func ReadBadBlock(db ethdb.Reader, hash common.Hash) *types.Block {
 	}
 	for _, bad := range badBlocks {
 		if bad.Header.Hash() == hash {
-			return types.NewBlockWithHeader(bad.Header).WithBody(bad.Body.Transactions, bad.Body.Uncles).WithWithdrawals(bad.Body.Withdrawals)
+			return types.NewBlockWithHeader(bad.Header).WithBody(bad.Body.Transactions, bad.Body.Uncles).WithWithdrawals(bad.Body.Withdrawals).WithBodyExtra(bad.Body)
 		}
 	}
 	return nil
@@ -862,7 +871,7 @@ func ReadAllBadBlocks(db ethdb.Reader) []*types.Block {
 	}
 	var blocks []*types.Block
 	for _, bad := range badBlocks {
-		blocks = append(blocks, types.NewBlockWithHeader(bad.Header).WithBody(bad.Body.Transactions, bad.Body.Uncles).WithWithdrawals(bad.Body.Withdrawals))
+		blocks = append(blocks, types.NewBlockWithHeader(bad.Header).WithBody(bad.Body.Transactions, bad.Body.Uncles).WithWithdrawals(bad.Body.Withdrawals).WithBodyExtra(bad.Body))
 	}
 	return blocks
 }
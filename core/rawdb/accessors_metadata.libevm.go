@@ -0,0 +1,75 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// UpgradeVersionedPayloads rewrites all stored data that can carry payloads
+// versioned with pseudo.RegisterVersions(), thus upgrading them to the latest
+// registered version, and returns the number of entries rewritten. Versioning
+// isn't supported for consensus-encoded payloads (e.g. state-account extras),
+// so the only such data are chain configs, with their [params.Extras].
+//
+// Decoding migrates earlier versions regardless, so it is only necessary to
+// run the upgrade before support for an earlier version is dropped. It is
+// exposed as the `geth db upgrade-payloads` command, which is only meaningful
+// in binaries that register the extras. It MUST NOT be run concurrently with
+// other writes to the chain configs, and is idempotent.
+func UpgradeVersionedPayloads(db ethdb.KeyValueStore) (int, error) {
+	it := db.NewIterator(configPrefix, nil)
+	defer it.Release()
+
+	var (
+		batch = db.NewBatch()
+		n     int
+	)
+	for it.Next() {
+		if len(it.Key()) != len(configPrefix)+common.HashLength {
+			continue
+		}
+		var config params.ChainConfig
+		if err := json.Unmarshal(it.Value(), &config); err != nil {
+			return 0, fmt.Errorf("decoding stored chain config %#x: %v", it.Key()[len(configPrefix):], err)
+		}
+		data, err := json.Marshal(&config)
+		if err != nil {
+			return 0, fmt.Errorf("encoding chain config %#x: %v", it.Key()[len(configPrefix):], err)
+		}
+		if bytes.Equal(data, it.Value()) {
+			continue
+		}
+		if err := batch.Put(common.CopyBytes(it.Key()), data); err != nil {
+			return 0, err
+		}
+		n++
+	}
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
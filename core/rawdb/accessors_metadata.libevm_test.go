@@ -0,0 +1,96 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type (
+	chainConfigExtraV0 struct {
+		ForkTime uint64 `json:"forkTime"`
+	}
+	chainConfigExtraV1 struct {
+		params.NOOPHooks
+		ForkTimes []uint64 `json:"forkTimes"`
+	}
+)
+
+func TestUpgradeVersionedPayloads(t *testing.T) {
+	params.TestOnlyClearRegisteredExtras()
+	t.Cleanup(params.TestOnlyClearRegisteredExtras)
+	pseudo.TestOnlyClearVersions()
+	t.Cleanup(pseudo.TestOnlyClearVersions)
+
+	payloads := params.RegisterExtras(params.Extras[chainConfigExtraV1, params.NOOPHooks]{})
+
+	db := NewMemoryDatabase()
+	n, err := UpgradeVersionedPayloads(db)
+	require.NoError(t, err, "UpgradeVersionedPayloads() without stored configs")
+	assert.Zero(t, n, "UpgradeVersionedPayloads() without stored configs")
+
+	// Simulates configs stored before the payload was versioned.
+	hashes := []common.Hash{{'g', 'e', 'n', 0}, {'g', 'e', 'n', 1}}
+	for i, hash := range hashes {
+		unversioned := fmt.Sprintf(`{"chainId":%d,"extra":{"forkTime":42}}`, i+1)
+		require.NoError(t, db.Put(configKey(hash), []byte(unversioned)), "db.Put()")
+	}
+	// Not a chain config, despite sharing its key prefix.
+	other := append(common.CopyBytes(configPrefix), 'x')
+	require.NoError(t, db.Put(other, []byte("not JSON")), "db.Put()")
+
+	pseudo.RegisterVersions[chainConfigExtraV1](
+		pseudo.Migrate(func(v chainConfigExtraV0) (chainConfigExtraV1, error) {
+			return chainConfigExtraV1{ForkTimes: []uint64{v.ForkTime}}, nil
+		}),
+	)
+
+	want := chainConfigExtraV1{ForkTimes: []uint64{42}}
+	for i, hash := range hashes {
+		cfg := ReadChainConfig(db, hash)
+		require.NotNil(t, cfg, "ReadChainConfig() before upgrade")
+		assert.Equal(t, big.NewInt(int64(i+1)), cfg.ChainID, "ChainID")
+		assert.Equal(t, want, payloads.FromChainConfig(cfg), "extra decoded and migrated from unversioned config")
+	}
+
+	n, err = UpgradeVersionedPayloads(db)
+	require.NoError(t, err, "UpgradeVersionedPayloads()")
+	assert.Equal(t, len(hashes), n, "number of entries upgraded")
+
+	for _, hash := range hashes {
+		data, err := db.Get(configKey(hash))
+		require.NoError(t, err, "db.Get()")
+		assert.Contains(t, string(data), `"extra":{"libevmPayloadVersion":1,"payload":{"forkTimes":[42]}}`, "upgraded config")
+
+		cfg := ReadChainConfig(db, hash)
+		require.NotNil(t, cfg, "ReadChainConfig() after upgrade")
+		assert.Equal(t, want, payloads.FromChainConfig(cfg), "extra decoded from upgraded config")
+	}
+
+	n, err = UpgradeVersionedPayloads(db)
+	require.NoError(t, err, "UpgradeVersionedPayloads() again")
+	assert.Zero(t, n, "number of entries upgraded again")
+}
@@ -58,6 +58,7 @@ type DumpAccount struct {
 	Address     *common.Address        `json:"address,omitempty"` // Address only present in iterative (line-by-line) mode
 	AddressHash hexutil.Bytes          `json:"key,omitempty"`     // If we don't have address, we can output the key
 
+	Extra *types.StateAccountExtra `json:"extra,omitempty"` // libevm: payload registered with [types.RegisterExtras]
 }
 
 // Dump represents the full dump in a collected format, as one large map.
@@ -100,6 +101,7 @@ func (d iterativeDump) OnAccount(addr *common.Address, account DumpAccount) {
 		Storage:     account.Storage,
 		AddressHash: account.AddressHash,
 		Address:     addr,
+		Extra:       account.Extra, // libevm
 	}
 	d.Encode(dumpAccount)
 }
@@ -145,6 +147,7 @@ func (s *StateDB) DumpToCollector(c DumpCollector, conf *DumpConfig) (nextKey []
 				Root:        data.Root[:],
 				CodeHash:    data.CodeHash,
 				AddressHash: it.Key,
+				Extra:       dumpExtra(&data), // libevm
 			}
 			address   *common.Address
 			addr      common.Address
@@ -183,6 +186,13 @@ func (s *StateDB) DumpToCollector(c DumpCollector, conf *DumpConfig) (nextKey []
 					log.Error("Failed to decode the value returned by iterator", "error", err)
 					continue
 				}
+				// libevm: strip any storage-slot extra payload from the value.
+				value, _, err := decodeSlot(content)
+				if err != nil {
+					log.Error("Failed to decode storage slot", "error", err)
+					continue
+				}
+				content = common.TrimLeftZeroes(value[:])
 				account.Storage[common.BytesToHash(s.trie.GetKey(storageIt.Key))] = common.Bytes2Hex(content)
 			}
 		}
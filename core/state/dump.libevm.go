@@ -0,0 +1,30 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package state
+
+import "github.com/ethereum/go-ethereum/core/types"
+
+// dumpExtra returns the account's extra payload for inclusion in a
+// [DumpAccount], or nil if no extras are registered. RLP decoding of a
+// [types.StateAccount] may populate its Extra field regardless of
+// registration, which would otherwise result in a spurious `null` payload.
+func dumpExtra(a *types.StateAccount) *types.StateAccountExtra {
+	if a.TypeErasedExtra() == nil {
+		return nil
+	}
+	return a.Extra
+}
@@ -19,46 +19,100 @@ package state
 import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/libevm"
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
 )
 
 // GetExtra returns the extra payload from the [types.StateAccount] associated
 // with the address, or a zero-value `SA` if not found. The
 // [types.ExtraPayloads] MUST be sourced from [types.RegisterExtras].
-func GetExtra[SA any](s *StateDB, p types.ExtraPayloads[SA], addr common.Address) SA {
-	stateObject := s.getStateObject(addr)
-	if stateObject != nil {
-		return p.FromStateAccount(&stateObject.data)
+//
+// The [libevm.StateReader] is typically a [*StateDB] but MAY be any
+// implementation, such as those received by precompiles and hooks.
+func GetExtra[HPtr types.HeaderHooks, BPtr types.BlockBodyHooks, SA any](s libevm.StateReader, _ types.ExtraPayloads[HPtr, BPtr, SA], addr common.Address) SA {
+	if t := s.GetAccountExtra(addr); t != nil {
+		return pseudo.MustNewValue[SA](t).Get()
 	}
 	var zero SA
 	return zero
 }
 
 // SetExtra sets the extra payload for the address. See [GetExtra] for details.
-func SetExtra[SA any](s *StateDB, p types.ExtraPayloads[SA], addr common.Address, extra SA) {
-	stateObject := s.getOrNewStateObject(addr)
-	if stateObject != nil {
-		setExtraOnObject(stateObject, p, addr, extra)
+// The change is journaled, so is undone by [StateDB.RevertToSnapshot].
+func SetExtra[HPtr types.HeaderHooks, BPtr types.BlockBodyHooks, SA any](s libevm.StateDB, _ types.ExtraPayloads[HPtr, BPtr, SA], addr common.Address, extra SA) {
+	s.SetAccountExtra(addr, pseudo.From(extra).Type)
+}
+
+// GetAccountExtra returns a type-erased copy of the extra payload from the
+// [types.StateAccount] associated with the address, or nil if not found. It
+// SHOULD NOT be used directly; instead use [GetExtra].
+func (s *StateDB) GetAccountExtra(addr common.Address) *pseudo.Type {
+	if stateObject := s.getStateObject(addr); stateObject != nil {
+		return stateObject.data.TypeErasedExtra()
 	}
+	return nil
 }
 
-func setExtraOnObject[SA any](s *stateObject, p types.ExtraPayloads[SA], addr common.Address, extra SA) {
-	s.db.journal.append(extraChange[SA]{
-		payloads: p,
-		account:  &addr,
-		prev:     p.FromStateAccount(&s.data),
+// SetAccountExtra sets the type-erased extra payload for the address. It
+// SHOULD NOT be used directly; instead use [SetExtra].
+func (s *StateDB) SetAccountExtra(addr common.Address, extra *pseudo.Type) {
+	if stateObject := s.getOrNewStateObject(addr); stateObject != nil {
+		stateObject.setExtra(extra)
+	}
+}
+
+func (s *stateObject) setExtra(extra *pseudo.Type) {
+	s.db.journal.append(extraChange{
+		account: &s.address,
+		prev:    s.data.TypeErasedExtra(),
 	})
-	p.SetOnStateAccount(&s.data, extra)
+	s.data.SetTypeErasedExtra(extra)
+}
+
+// extraChange is a [journalEntry] for [stateObject.setExtra].
+type extraChange struct {
+	account *common.Address
+	prev    *pseudo.Type
+}
+
+func (e extraChange) dirtied() *common.Address { return e.account }
+
+func (e extraChange) revert(s *StateDB) {
+	s.getStateObject(*e.account).data.SetTypeErasedExtra(e.prev)
 }
 
-// extraChange is a [journalEntry] for [SetExtra] / [setExtraOnObject].
-type extraChange[SA any] struct {
-	payloads types.ExtraPayloads[SA]
-	account  *common.Address
-	prev     SA
+// GetSlotExtra returns the extra payload of the storage slot, or a zero-value
+// `SS` if not found. The [StorageSlotExtraPayloads] MUST be sourced from
+// [RegisterStorageSlotExtras]. See [GetExtra] re the [libevm.StateReader].
+func GetSlotExtra[SS any](s libevm.StateReader, _ StorageSlotExtraPayloads[SS], addr common.Address, key common.Hash) SS {
+	if t := s.GetStorageSlotExtra(addr, key); t != nil {
+		return pseudo.MustNewValue[SS](t).Get()
+	}
+	var zero SS
+	return zero
 }
 
-func (e extraChange[SA]) dirtied() *common.Address { return e.account }
+// SetSlotExtra sets the extra payload of the storage slot. See
+// [GetSlotExtra] for details. The change is journaled, so is undone by
+// [StateDB.RevertToSnapshot].
+func SetSlotExtra[SS any](s libevm.StateDB, _ StorageSlotExtraPayloads[SS], addr common.Address, key common.Hash, extra SS) {
+	s.SetStorageSlotExtra(addr, key, pseudo.From(extra).Type)
+}
 
-func (e extraChange[SA]) revert(s *StateDB) {
-	e.payloads.SetOnStateAccount(&s.getStateObject(*e.account).data, e.prev)
+// GetStorageSlotExtra returns the type-erased extra payload of the storage
+// slot, or nil if not found. The returned value MUST NOT be modified. It SHOULD
+// NOT be used directly; instead use [GetSlotExtra].
+func (s *StateDB) GetStorageSlotExtra(addr common.Address, key common.Hash) *pseudo.Type {
+	if stateObject := s.getStateObject(addr); stateObject != nil {
+		return stateObject.getSlotExtra(key)
+	}
+	return nil
+}
+
+// SetStorageSlotExtra sets the type-erased extra payload of the storage slot.
+// It SHOULD NOT be used directly; instead use [SetSlotExtra].
+func (s *StateDB) SetStorageSlotExtra(addr common.Address, key common.Hash, extra *pseudo.Type) {
+	if stateObject := s.getOrNewStateObject(addr); stateObject != nil {
+		stateObject.setSlotExtra(key, extra)
+	}
 }
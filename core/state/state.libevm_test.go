@@ -17,7 +17,9 @@
 package state_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 	"testing"
 
@@ -31,7 +33,10 @@ import (
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/libevm"
 	"github.com/ethereum/go-ethereum/libevm/ethtest"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
 )
 
@@ -45,7 +50,7 @@ func TestGetSetExtra(t *testing.T) {
 	t.Cleanup(types.TestOnlyClearRegisteredExtras)
 	// Just as its Data field is a pointer, the registered type is a pointer to
 	// test deep copying.
-	payloads := types.RegisterExtras[*accountExtra]()
+	payloads := types.RegisterExtras[types.NOOPHeaderHooks, *types.NOOPHeaderHooks, types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks, *accountExtra]()
 
 	rng := ethtest.NewPseudoRand(42)
 	addr := rng.Address()
@@ -170,3 +175,184 @@ func newWithSnaps(t *testing.T) stateViews {
 		database: state.NewDatabase(ethDB),
 	}
 }
+
+func TestSlotExtras(t *testing.T) {
+	type rent struct {
+		LastTouched uint64
+		Paid        uint64
+	}
+
+	state.TestOnlyClearRegisteredStorageSlotExtras()
+	t.Cleanup(state.TestOnlyClearRegisteredStorageSlotExtras)
+	payloads := state.RegisterStorageSlotExtras[rent]()
+
+	rng := ethtest.NewPseudoRand(1729)
+	addr := rng.Address()
+	withExtra := rng.Hash()
+	withoutExtra := rng.Hash()
+	val := rng.Hash()
+	extra := rent{LastTouched: 42, Paid: 1e6}
+
+	// newState returns a StateDB with both slots set to `val`, and `extra`
+	// set if requested, along with the committed root.
+	newState := func(t *testing.T, views stateViews, setExtra bool) (*state.StateDB, common.Hash) {
+		t.Helper()
+		s := views.newStateDB(t, types.EmptyRootHash)
+		s.SetNonce(addr, 1)
+		s.SetState(addr, withExtra, val)
+		s.SetState(addr, withoutExtra, val)
+		if setExtra {
+			state.SetSlotExtra(s, payloads, addr, withExtra, extra)
+		}
+		root, err := s.Commit(1, true)
+		require.NoErrorf(t, err, "%T.Commit()", s)
+		return s, root
+	}
+
+	views := newWithSnaps(t)
+	_, legacyRoot := newState(t, views, false)
+	_, root := newState(t, views, true)
+	require.NotEqual(t, legacyRoot, root, "state root with and without slot extras")
+
+	t.Run("zero_value_extra", func(t *testing.T) {
+		views := newWithSnaps(t)
+		s := views.newStateDB(t, types.EmptyRootHash)
+		s.SetNonce(addr, 1)
+		s.SetState(addr, withExtra, val)
+		s.SetState(addr, withoutExtra, val)
+		state.SetSlotExtra(s, payloads, addr, withExtra, rent{})
+		got, err := s.Commit(1, true)
+		require.NoErrorf(t, err, "%T.Commit()", s)
+		assert.Equal(t, legacyRoot, got, "state root with zero-value slot extra")
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		for _, snaps := range []bool{true, false} {
+			t.Run(fmt.Sprintf("snapshots=%t", snaps), func(t *testing.T) {
+				v := views
+				if !snaps {
+					v.snaps = nil
+				}
+				var sr libevm.StateReader = v.newStateDB(t, root)
+				assert.Equal(t, val, sr.GetState(addr, withExtra), "GetState() of slot with extra")
+				assert.Equal(t, extra, state.GetSlotExtra(sr, payloads, addr, withExtra), "GetSlotExtra()")
+				assert.Zero(t, state.GetSlotExtra(sr, payloads, addr, withoutExtra), "GetSlotExtra() of slot without extra")
+			})
+		}
+	})
+
+	t.Run("revert", func(t *testing.T) {
+		s := views.newStateDB(t, root)
+		snap := s.Snapshot()
+		state.SetSlotExtra(s, payloads, addr, withExtra, rent{})
+		state.SetSlotExtra(s, payloads, addr, withoutExtra, extra)
+		s.RevertToSnapshot(snap)
+		assert.Equal(t, extra, state.GetSlotExtra(s, payloads, addr, withExtra), "GetSlotExtra() after revert")
+		assert.Zero(t, state.GetSlotExtra(s, payloads, addr, withoutExtra), "GetSlotExtra() after revert")
+
+		got, err := s.Commit(2, true)
+		require.NoErrorf(t, err, "%T.Commit()", s)
+		assert.Equal(t, root, got, "state root after reverted changes")
+	})
+
+	t.Run("only_extra_modified", func(t *testing.T) {
+		s := views.newStateDB(t, root)
+		newExtra := rent{LastTouched: 43, Paid: 2e6}
+		state.SetSlotExtra(s, payloads, addr, withExtra, newExtra)
+		newRoot, err := s.Commit(2, true)
+		require.NoErrorf(t, err, "%T.Commit()", s)
+		require.NotEqual(t, root, newRoot, "state root after modifying only slot extra")
+
+		reloaded := views.newStateDB(t, newRoot)
+		assert.Equal(t, val, reloaded.GetState(addr, withExtra), "GetState() unchanged")
+		assert.Equal(t, newExtra, state.GetSlotExtra(reloaded, payloads, addr, withExtra), "GetSlotExtra()")
+	})
+
+	t.Run("cleared_value", func(t *testing.T) {
+		s := views.newStateDB(t, root)
+		s.SetState(addr, withExtra, common.Hash{})
+		s.SetState(addr, withoutExtra, common.Hash{})
+		newRoot, err := s.Commit(2, true)
+		require.NoErrorf(t, err, "%T.Commit()", s)
+
+		assert.Zero(t, state.GetSlotExtra(s, payloads, addr, withExtra), "GetSlotExtra() of cleared slot")
+		assert.Equal(t, types.EmptyRootHash, views.newStateDB(t, newRoot).GetStorageRoot(addr), "storage root after clearing slots")
+	})
+
+	t.Run("DecodeSlotValue", func(t *testing.T) {
+		s := views.newStateDB(t, root)
+		tr, err := views.database.OpenStorageTrie(root, addr, s.GetStorageRoot(addr), nil)
+		require.NoError(t, err, "OpenStorageTrie()")
+		nodes, err := tr.NodeIterator(nil)
+		require.NoError(t, err, "NodeIterator()")
+
+		var n int
+		for it := trie.NewIterator(nodes); it.Next(); n++ {
+			_, content, _, err := rlp.Split(it.Value)
+			require.NoError(t, err, "rlp.Split()")
+			got, err := state.DecodeSlotValue(content)
+			require.NoError(t, err, "DecodeSlotValue()")
+			assert.Equal(t, val, got, "DecodeSlotValue()")
+		}
+		require.NoError(t, nodes.Error(), "iterating storage trie")
+		assert.Equal(t, 2, n, "number of slots")
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		orig := views.newStateDB(t, root)
+		cp := orig.Copy()
+		state.SetSlotExtra(orig, payloads, addr, withExtra, rent{})
+		assert.Zero(t, state.GetSlotExtra(orig, payloads, addr, withExtra), "GetSlotExtra([original]) after setting")
+		assert.Equal(t, extra, state.GetSlotExtra(cp, payloads, addr, withExtra), "GetSlotExtra([copy]) unaffected by setting on original")
+	})
+}
+
+func TestDumpExtras(t *testing.T) {
+	type accountExtra struct {
+		Rent uint64 `json:"rent"`
+	}
+
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	payloads := types.RegisterExtras[
+		types.NOOPHeaderHooks, *types.NOOPHeaderHooks,
+		types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks,
+		accountExtra,
+	]()
+	state.TestOnlyClearRegisteredStorageSlotExtras()
+	t.Cleanup(state.TestOnlyClearRegisteredStorageSlotExtras)
+	slotPayloads := state.RegisterStorageSlotExtras[uint64]()
+
+	db := state.NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &triedb.Config{Preimages: true})
+	sdb, err := state.New(types.EmptyRootHash, db, nil)
+	require.NoError(t, err, "state.New()")
+
+	addr := common.Address{'d', 'u', 'm', 'p'}
+	key := common.Hash{'k', 'e', 'y'}
+	sdb.SetNonce(addr, 1)
+	sdb.SetState(addr, key, common.BigToHash(big.NewInt(0xabcd)))
+	state.SetSlotExtra(sdb, slotPayloads, addr, key, 42)
+	state.SetExtra(sdb, payloads, addr, accountExtra{Rent: 1729})
+
+	root, err := sdb.Commit(0, false)
+	require.NoErrorf(t, err, "%T.Commit()", sdb)
+	sdb, err = state.New(root, db, nil)
+	require.NoError(t, err, "state.New([committed root])")
+
+	buf := sdb.Dump(nil)
+	var dump struct {
+		Accounts map[string]struct {
+			Storage map[common.Hash]string `json:"storage"`
+			Extra   json.RawMessage        `json:"extra"`
+		} `json:"accounts"`
+	}
+	require.NoErrorf(t, json.Unmarshal(buf, &dump), "json.Unmarshal(%T.Dump())", sdb)
+
+	got, ok := dump.Accounts[addr.Hex()]
+	require.Truef(t, ok, "dumped accounts include %v", addr)
+	assert.JSONEq(t, `{"rent":1729}`, string(got.Extra), "dumped account extra")
+	assert.Equal(t, "abcd", got.Storage[key], "dumped storage value with slot extra")
+
+	raw := sdb.RawDump(nil)
+	assert.Equal(t, accountExtra{Rent: 1729}, payloads.FromStateAccount(&types.StateAccount{Extra: raw.Accounts[addr.Hex()].Extra}), "RawDump() account extra")
+}
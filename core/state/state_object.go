@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie/trienode"
@@ -75,6 +76,10 @@ type stateObject struct {
 	pendingStorage Storage // Storage entries that need to be flushed to disk, at the end of an entire block
 	dirtyStorage   Storage // Storage entries that have been modified in the current transaction execution, reset for every transaction
 
+	// libevm: payloads registered with RegisterStorageSlotExtras(), mirroring
+	// the above storage caches.
+	slotExtras slotExtras
+
 	// Cache flags.
 	dirtyCode bool // true if the code was updated
 
@@ -193,6 +198,7 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 		enc   []byte
 		err   error
 		value common.Hash
+		extra *pseudo.Type // libevm
 	)
 	if s.db.snap != nil {
 		start := time.Now()
@@ -205,7 +211,7 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 			if err != nil {
 				s.db.setError(err)
 			}
-			value.SetBytes(content)
+			value, extra = s.decodeSlot(content) // libevm: equivalent to value.SetBytes(content) without extras
 		}
 	}
 	// If the snapshot is unavailable or reading from it fails, load from the database.
@@ -224,9 +230,12 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 			s.db.setError(err)
 			return common.Hash{}
 		}
-		value.SetBytes(val)
+		value, extra = s.decodeSlot(val) // libevm: equivalent to value.SetBytes(val) without extras
 	}
 	s.originStorage[key] = value
+	if extra != nil { // libevm
+		setSlotExtra(&s.slotExtras.origin, key, extra)
+	}
 	return value
 }
 
@@ -266,6 +275,7 @@ func (s *stateObject) finalise(prefetch bool) {
 	if len(s.dirtyStorage) > 0 {
 		s.dirtyStorage = make(Storage)
 	}
+	s.finaliseSlotExtras() // libevm
 }
 
 // updateTrie is responsible for persisting cached storage changes into the
@@ -277,6 +287,7 @@ func (s *stateObject) finalise(prefetch bool) {
 func (s *stateObject) updateTrie() (Trie, error) {
 	// Make sure all dirty slots are finalized into the pending storage area
 	s.finalise(false)
+	s.mergePendingSlotExtras() // libevm
 
 	// Short circuit if nothing changed, don't bother with hashing anything
 	if len(s.pendingStorage) == 0 {
@@ -300,11 +311,12 @@ func (s *stateObject) updateTrie() (Trie, error) {
 	usedStorage := make([][]byte, 0, len(s.pendingStorage))
 	for key, value := range s.pendingStorage {
 		// Skip noop changes, persist actual changes
-		if value == s.originStorage[key] {
+		if value == s.originStorage[key] && !s.slotExtraChanged(key, value) { // libevm: also persist changes to only extras
 			continue
 		}
 		prev := s.originStorage[key]
 		s.originStorage[key] = value
+		prevExtra, extra := s.commitSlotExtra(key, value) // libevm
 
 		var encoded []byte // rlp-encoded value to be used by the snapshot
 		if (value == common.Hash{}) {
@@ -314,8 +326,13 @@ func (s *stateObject) updateTrie() (Trie, error) {
 			}
 			s.db.StorageDeleted += 1
 		} else {
+			// libevm: equivalent to trimming leading zeroes if there is no extra.
+			trimmed, err := encodeSlot(value, extra)
+			if err != nil {
+				s.db.setError(err)
+				return nil, err
+			}
 			// Encoding []byte cannot fail, ok to ignore the error.
-			trimmed := common.TrimLeftZeroes(value[:])
 			encoded, _ = rlp.EncodeToBytes(trimmed)
 			if err := tr.UpdateStorage(s.address, key[:], trimmed); err != nil {
 				s.db.setError(err)
@@ -345,8 +362,14 @@ func (s *stateObject) updateTrie() (Trie, error) {
 			if prev == (common.Hash{}) {
 				origin[khash] = nil // nil if it was not present previously
 			} else {
+				// libevm: equivalent to trimming leading zeroes if there is no extra.
+				trimmed, err := encodeSlot(prev, prevExtra)
+				if err != nil {
+					s.db.setError(err)
+					return nil, err
+				}
 				// Encoding []byte cannot fail, ok to ignore the error.
-				b, _ := rlp.EncodeToBytes(common.TrimLeftZeroes(prev[:]))
+				b, _ := rlp.EncodeToBytes(trimmed)
 				origin[khash] = b
 			}
 		}
@@ -357,6 +380,7 @@ func (s *stateObject) updateTrie() (Trie, error) {
 		s.db.prefetcher.used(s.addrHash, s.data.Root, usedStorage)
 	}
 	s.pendingStorage = make(Storage) // reset pending map
+	s.slotExtras.pending = nil       // libevm
 	return tr, nil
 }
 
@@ -453,6 +477,7 @@ func (s *stateObject) deepCopy(db *StateDB) *stateObject {
 	obj.dirtyStorage = s.dirtyStorage.Copy()
 	obj.originStorage = s.originStorage.Copy()
 	obj.pendingStorage = s.pendingStorage.Copy()
+	obj.slotExtras = s.slotExtras.copy() // libevm
 	obj.selfDestructed = s.selfDestructed
 	obj.dirtyCode = s.dirtyCode
 	obj.deleted = s.deleted
@@ -0,0 +1,281 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
+	"github.com/ethereum/go-ethereum/libevm/testonly"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// RegisterStorageSlotExtras registers the type `SS` to be carried as an extra
+// payload alongside the value of every storage slot. It is expected to be
+// called in an `init()` function and MUST NOT be called more than once.
+//
+// Payloads are journaled, so are undone by [StateDB.RevertToSnapshot], and are
+// persisted in the storage trie and snapshot. A slot with a zero-value payload
+// is encoded exactly as it would be without registration, so existing state
+// roots are unaffected until a payload is set. Payloads are not persisted for
+// slots with zero values as such slots are deleted. Payloads are not supported
+// by verkle tries.
+//
+// The payloads can be accessed with [GetSlotExtra] and [SetSlotExtra]. As they
+// are part of the state root, their encoding can't be versioned with
+// [pseudo.RegisterVersions]; see [pseudo.RequireUnversioned].
+func RegisterStorageSlotExtras[SS any]() StorageSlotExtraPayloads[SS] {
+	if registeredSlotExtras != nil {
+		panic("re-registration of storage-slot extras")
+	}
+	pseudo.RequireUnversioned[SS]("storage-slot extras are part of the state root")
+	registeredSlotExtras = &slotExtrasConstructors{
+		newSlot: pseudo.NewConstructor[SS]().Zero,
+		check: func(t *pseudo.Type) {
+			pseudo.MustNewValue[SS](t)
+		},
+	}
+	return StorageSlotExtraPayloads[SS]{}
+}
+
+// TestOnlyClearRegisteredStorageSlotExtras clears the payload type previously
+// passed to [RegisterStorageSlotExtras]. It panics if called from a non-testing
+// call stack.
+func TestOnlyClearRegisteredStorageSlotExtras() {
+	testonly.OrPanic(func() {
+		registeredSlotExtras = nil
+	})
+}
+
+var registeredSlotExtras *slotExtrasConstructors
+
+type slotExtrasConstructors struct {
+	newSlot func() *pseudo.Type
+	check   func(*pseudo.Type) // panics on incorrect type
+}
+
+// StorageSlotExtraPayloads provides strongly typed access to storage-slot
+// payloads. The only valid way to construct an instance is by a call to
+// [RegisterStorageSlotExtras].
+type StorageSlotExtraPayloads[SS any] struct {
+	_ struct{} // see [types.ExtraPayloads]
+}
+
+// slotExtras mirror the origin, pending, and dirty storage caches of a
+// [stateObject], holding the respective payloads. A nil *pseudo.Type is
+// equivalent to a zero-value payload.
+type slotExtras struct {
+	origin, pending, dirty map[common.Hash]*pseudo.Type
+}
+
+func (e *slotExtras) copy() slotExtras {
+	cp := func(m map[common.Hash]*pseudo.Type) map[common.Hash]*pseudo.Type {
+		if m == nil {
+			return nil
+		}
+		c := make(map[common.Hash]*pseudo.Type, len(m))
+		for k, v := range m {
+			c[k] = v
+		}
+		return c
+	}
+	return slotExtras{
+		origin:  cp(e.origin),
+		pending: cp(e.pending),
+		dirty:   cp(e.dirty),
+	}
+}
+
+func setSlotExtra(m *map[common.Hash]*pseudo.Type, key common.Hash, extra *pseudo.Type) {
+	if *m == nil {
+		*m = make(map[common.Hash]*pseudo.Type)
+	}
+	(*m)[key] = extra
+}
+
+func isZeroSlotExtra(t *pseudo.Type) bool {
+	return t == nil || t.IsZero()
+}
+
+func slotExtrasEqual(a, b *pseudo.Type) bool {
+	if isZeroSlotExtra(a) || isZeroSlotExtra(b) {
+		return isZeroSlotExtra(a) && isZeroSlotExtra(b)
+	}
+	return a.Equal(b)
+}
+
+// getSlotExtra is the extras equivalent of [stateObject.GetState].
+func (s *stateObject) getSlotExtra(key common.Hash) *pseudo.Type {
+	if registeredSlotExtras == nil {
+		return nil
+	}
+	if t, dirty := s.slotExtras.dirty[key]; dirty {
+		return t
+	}
+	return s.getCommittedSlotExtra(key)
+}
+
+// getCommittedSlotExtra is the extras equivalent of
+// [stateObject.GetCommittedState].
+func (s *stateObject) getCommittedSlotExtra(key common.Hash) *pseudo.Type {
+	if t, pending := s.slotExtras.pending[key]; pending {
+		return t
+	}
+	// Loading the committed value also populates the origin extras.
+	s.GetCommittedState(key)
+	return s.slotExtras.origin[key]
+}
+
+// setSlotExtra is the extras equivalent of [stateObject.SetState].
+func (s *stateObject) setSlotExtra(key common.Hash, extra *pseudo.Type) {
+	if registeredSlotExtras == nil {
+		panic("no storage-slot extras registered")
+	}
+	registeredSlotExtras.check(extra)
+
+	prev := s.getSlotExtra(key)
+	if slotExtrasEqual(prev, extra) {
+		return
+	}
+	s.db.journal.append(slotExtraChange{
+		account: &s.address,
+		key:     key,
+		prev:    prev,
+	})
+	setSlotExtra(&s.slotExtras.dirty, key, extra)
+}
+
+// slotExtraChange is a [journalEntry] for [stateObject.setSlotExtra].
+type slotExtraChange struct {
+	account *common.Address
+	key     common.Hash
+	prev    *pseudo.Type
+}
+
+func (ch slotExtraChange) dirtied() *common.Address { return ch.account }
+
+func (ch slotExtraChange) revert(s *StateDB) {
+	setSlotExtra(&s.getStateObject(*ch.account).slotExtras.dirty, ch.key, ch.prev)
+}
+
+// finaliseSlotExtras is the extras equivalent of [stateObject.finalise].
+func (s *stateObject) finaliseSlotExtras() {
+	for key, t := range s.slotExtras.dirty {
+		setSlotExtra(&s.slotExtras.pending, key, t)
+	}
+	s.slotExtras.dirty = nil
+}
+
+// mergePendingSlotExtras ensures that every slot with a pending payload also
+// has a pending value, so that [stateObject.updateTrie] persists slots for
+// which only the payload was modified.
+func (s *stateObject) mergePendingSlotExtras() {
+	for key := range s.slotExtras.pending {
+		if _, ok := s.pendingStorage[key]; !ok {
+			s.pendingStorage[key] = s.GetCommittedState(key)
+		}
+	}
+}
+
+// slotExtraChanged reports whether the slot's pending payload differs from
+// its committed one. Payloads of zero-value slots are ignored as the slots
+// are deleted.
+func (s *stateObject) slotExtraChanged(key, value common.Hash) bool {
+	if value == (common.Hash{}) {
+		return false
+	}
+	t, pending := s.slotExtras.pending[key]
+	return pending && !slotExtrasEqual(t, s.slotExtras.origin[key])
+}
+
+// commitSlotExtra moves the slot's pending payload, if any, to the origin
+// cache, returning the previous and new origin payloads.
+func (s *stateObject) commitSlotExtra(key, value common.Hash) (prev, next *pseudo.Type) {
+	prev = s.slotExtras.origin[key]
+	next = prev
+	if t, pending := s.slotExtras.pending[key]; pending {
+		next = t
+	}
+	if value == (common.Hash{}) {
+		next = nil // deleted from the trie
+	}
+	if prev != nil || next != nil {
+		setSlotExtra(&s.slotExtras.origin, key, next)
+	}
+	return prev, next
+}
+
+// slotMarker is prepended to the encoding of storage slots that carry a
+// non-zero payload. Regular slot encodings never start with a zero byte as
+// values are trimmed of leading zeroes, and zero values are deleted.
+const slotMarker = 0
+
+type slotWithExtra struct {
+	Value []byte
+	Extra *pseudo.Type
+}
+
+// encodeSlot returns the value to be stored in the storage trie, which is
+// then RLP encoded by the trie. This is equivalent to the trimmed value unless
+// the payload is non-zero.
+func encodeSlot(value common.Hash, extra *pseudo.Type) ([]byte, error) {
+	trimmed := common.TrimLeftZeroes(value[:])
+	if isZeroSlotExtra(extra) {
+		return trimmed, nil
+	}
+	buf, err := rlp.EncodeToBytes(slotWithExtra{trimmed, extra})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{slotMarker}, buf...), nil
+}
+
+// decodeSlot is the inverse of [encodeSlot]; the returned payload is nil if the
+// slot doesn't carry one.
+func decodeSlot(content []byte) (common.Hash, *pseudo.Type, error) {
+	if len(content) == 0 || content[0] != slotMarker {
+		return common.BytesToHash(content), nil, nil
+	}
+	if registeredSlotExtras == nil {
+		return common.Hash{}, nil, errors.New("storage slot with extra payload but no extras registered")
+	}
+	slot := slotWithExtra{Extra: registeredSlotExtras.newSlot()}
+	if err := rlp.DecodeBytes(content[1:], &slot); err != nil {
+		return common.Hash{}, nil, err
+	}
+	return common.BytesToHash(slot.Value), slot.Extra, nil
+}
+
+// DecodeSlotValue returns the value of a storage slot from its content as held
+// in the storage trie (i.e. the RLP string content of the trie value),
+// discarding any payload registered with [RegisterStorageSlotExtras]. It MUST be
+// used instead of [common.BytesToHash] by code that iterates over storage
+// tries directly instead of via a [StateDB].
+func DecodeSlotValue(content []byte) (common.Hash, error) {
+	value, _, err := decodeSlot(content)
+	return value, err
+}
+
+// decodeSlot calls [decodeSlot], recording any error on the [StateDB].
+func (s *stateObject) decodeSlot(content []byte) (common.Hash, *pseudo.Type) {
+	value, extra, err := decodeSlot(content)
+	if err != nil {
+		s.db.setError(err)
+	}
+	return value, extra
+}
@@ -27,7 +27,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -79,6 +78,9 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	if beaconRoot := block.BeaconRoot(); beaconRoot != nil {
 		ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
 	}
+	if err := ProcessPreBlockHooks(header, vmenv); err != nil { // libevm
+		return nil, nil, 0, fmt.Errorf("pre-block hooks: %w", err)
+	}
 	// Iterate over and process the individual transactions
 	for i, tx := range block.Transactions() {
 		msg, err := TransactionToMessage(tx, signer, header.BaseFee)
@@ -93,6 +95,11 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, receipt.Logs...)
 	}
+	// libevm: the post-block hooks MUST NOT see the last transaction's context.
+	vmenv.Reset(vm.TxContext{}, statedb)
+	if err := ProcessPostBlockHooks(header, vmenv); err != nil { // libevm
+		return nil, nil, 0, fmt.Errorf("post-block hooks: %w", err)
+	}
 	// Fail if Shanghai not enabled and len(withdrawals) is non-zero.
 	withdrawals := block.Withdrawals()
 	if len(withdrawals) > 0 && !p.config.IsShanghai(block.Number(), block.Time()) {
@@ -142,7 +149,8 @@ func applyTransaction(msg *Message, config *params.ChainConfig, gp *GasPool, sta
 
 	// If the transaction created a contract, store the creation address in the receipt.
 	if msg.To == nil {
-		receipt.ContractAddress = crypto.CreateAddress(evm.TxContext.Origin, tx.Nonce())
+		rules := evmRules(evm)                                                          // libevm
+		receipt.ContractAddress = rules.CreateAddress(evm.TxContext.Origin, tx.Nonce()) // libevm: overridable
 	}
 
 	// Set the receipt logs and create the bloom filter.
@@ -0,0 +1,96 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/libevm/testonly"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// RegisterHooks registers the Hooks. It is expected to be called in an `init()`
+// function and MUST NOT be called more than once.
+func RegisterHooks(h Hooks) {
+	if libevmHooks != nil {
+		panic("already registered")
+	}
+	libevmHooks = h
+}
+
+var libevmHooks Hooks
+
+// Hooks are arbitrary configuration functions to modify default block
+// processing. See [RegisterHooks].
+//
+// The hooks are called by [StateProcessor.Process], [GenerateChain], by the
+// miner when building blocks, and wherever blocks are replayed (e.g. for
+// tracing), so MUST be deterministic. The [vm.EVM] has
+// an empty [vm.TxContext] and its StateDB is that of the block being
+// processed, so MAY be used for system calls.
+type Hooks interface {
+	// PreBlock is called before the first transaction in every block, after
+	// any native system calls (e.g. EIP-4788).
+	PreBlock(params.Rules, *types.Header, *vm.EVM) error
+	// PostBlock is called after the last transaction in every block, before
+	// the consensus engine finalises the block.
+	PostBlock(params.Rules, *types.Header, *vm.EVM) error
+}
+
+// NOOPHooks implements [Hooks] such that they are equivalent to no hooks
+// having been registered. Implementations that only wish to modify a subset of
+// behaviour SHOULD embed NOOPHooks.
+type NOOPHooks struct{}
+
+var _ Hooks = NOOPHooks{}
+
+// PreBlock returns nil.
+func (NOOPHooks) PreBlock(params.Rules, *types.Header, *vm.EVM) error { return nil }
+
+// PostBlock returns nil.
+func (NOOPHooks) PostBlock(params.Rules, *types.Header, *vm.EVM) error { return nil }
+
+// ProcessPreBlockHooks calls [Hooks.PreBlock] if hooks are registered,
+// otherwise it is a no-op.
+func ProcessPreBlockHooks(header *types.Header, vmenv *vm.EVM) error {
+	if libevmHooks == nil {
+		return nil
+	}
+	return libevmHooks.PreBlock(evmRules(vmenv), header, vmenv)
+}
+
+// ProcessPostBlockHooks calls [Hooks.PostBlock] if hooks are registered,
+// otherwise it is a no-op.
+func ProcessPostBlockHooks(header *types.Header, vmenv *vm.EVM) error {
+	if libevmHooks == nil {
+		return nil
+	}
+	return libevmHooks.PostBlock(evmRules(vmenv), header, vmenv)
+}
+
+func evmRules(evm *vm.EVM) params.Rules {
+	bCtx := evm.Context
+	return evm.ChainConfig().Rules(bCtx.BlockNumber, bCtx.Random != nil, bCtx.Time)
+}
+
+// TestOnlyClearRegisteredHooks clears the [Hooks] previously passed to
+// [RegisterHooks]. It panics if called from a non-testing call stack.
+func TestOnlyClearRegisteredHooks() {
+	testonly.OrPanic(func() {
+		libevmHooks = nil
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm/ethtest"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// blockHooks record, in the state of `system`, the balance of `recipient`
+// before and after each block's transactions, and make a system call to a
+// contract that counts the number of blocks.
+type blockHooks struct {
+	core.NOOPHooks
+	system, recipient, counter common.Address
+	preBlockErr                error
+}
+
+var (
+	preBlockSlot  = common.Hash{'p', 'r', 'e'}
+	postBlockSlot = common.Hash{'p', 'o', 's', 't'}
+)
+
+func (h *blockHooks) record(header *types.Header, evm *vm.EVM, base common.Hash) {
+	slot := new(big.Int).Add(base.Big(), header.Number)
+	evm.StateDB.SetState(h.system, common.BigToHash(slot), evm.StateDB.GetBalance(h.recipient).Bytes32())
+}
+
+func (h *blockHooks) PreBlock(_ params.Rules, header *types.Header, evm *vm.EVM) error {
+	if h.preBlockErr != nil {
+		return h.preBlockErr
+	}
+	h.record(header, evm, preBlockSlot)
+	return nil
+}
+
+func (h *blockHooks) PostBlock(_ params.Rules, header *types.Header, evm *vm.EVM) error {
+	if evm.TxContext.Origin != (common.Address{}) {
+		return errors.New("non-empty TxContext")
+	}
+	h.record(header, evm, postBlockSlot)
+	evm.StateDB.AddAddressToAccessList(h.counter)
+	_, _, err := evm.Call(vm.AccountRef(h.system), h.counter, nil, 1e6, new(uint256.Int))
+	return err
+}
+
+func TestBlockHooks(t *testing.T) {
+	rng := ethtest.NewPseudoRand(42)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err, "crypto.GenerateKey()")
+	eoa := crypto.PubkeyToAddress(key.PublicKey)
+
+	hooks := &blockHooks{
+		system:    rng.Address(),
+		recipient: rng.Address(),
+		counter:   rng.Address(),
+	}
+	core.RegisterHooks(hooks)
+	t.Cleanup(core.TestOnlyClearRegisteredHooks)
+
+	counterCode := []byte{
+		byte(vm.PUSH1), 0, byte(vm.SLOAD),
+		byte(vm.PUSH1), 1, byte(vm.ADD),
+		byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			eoa:           {Balance: big.NewInt(params.Ether)},
+			hooks.counter: {Code: counterCode},
+			// Storage of empty accounts is removed under EIP-158.
+			hooks.system: {Nonce: 1},
+		},
+	}
+	signer := types.LatestSigner(gspec.Config)
+
+	const (
+		numBlocks = 3
+		txBlock   = 1 // index, not number
+		value     = 42
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), numBlocks, func(i int, b *core.BlockGen) {
+		if i != txBlock {
+			return
+		}
+		tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   gspec.Config.ChainID,
+			To:        &hooks.recipient,
+			Value:     big.NewInt(value),
+			Gas:       params.TxGas,
+			GasFeeCap: b.BaseFee(),
+		})
+		b.AddTx(tx)
+	})
+
+	t.Run("StateProcessor.Process", func(t *testing.T) {
+		chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+		require.NoError(t, err, "core.NewBlockChain()")
+		defer chain.Stop()
+
+		// Any difference between GenerateChain and Process would result in a
+		// state-root mismatch.
+		_, err = chain.InsertChain(blocks)
+		require.NoError(t, err, "InsertChain()")
+
+		state, err := chain.State()
+		require.NoError(t, err, "State()")
+
+		assert.Equal(t, common.BigToHash(big.NewInt(numBlocks)), state.GetState(hooks.counter, common.Hash{}), "system calls to counter")
+
+		for i, b := range blocks {
+			var pre, post uint64
+			if i > txBlock {
+				pre = value
+			}
+			if i >= txBlock {
+				post = value
+			}
+			slot := func(base common.Hash) common.Hash {
+				return common.BigToHash(new(big.Int).Add(base.Big(), b.Number()))
+			}
+			assert.Equalf(t, uint256.NewInt(pre).Bytes32(), [32]byte(state.GetState(hooks.system, slot(preBlockSlot))), "balance before transactions in block %d", b.Number())
+			assert.Equalf(t, uint256.NewInt(post).Bytes32(), [32]byte(state.GetState(hooks.system, slot(postBlockSlot))), "balance after transactions in block %d", b.Number())
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+		require.NoError(t, err, "core.NewBlockChain()")
+		defer chain.Stop()
+
+		errTest := errors.New("uh oh")
+		hooks.preBlockErr = errTest
+		t.Cleanup(func() { hooks.preBlockErr = nil })
+
+		_, err = chain.InsertChain(blocks)
+		require.ErrorIs(t, err, errTest, "InsertChain() with erroring PreBlock() hook")
+	})
+}
@@ -15,10 +15,154 @@
 // <http://www.gnu.org/licenses/>.
 package core
 
+import (
+	"context"
+	"fmt"
+
+	"github.com/holiman/uint256"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/libevm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
 // canExecuteTransaction is a convenience wrapper for calling the
 // [params.RulesHooks.CanExecuteTransaction] hook.
 func (st *StateTransition) canExecuteTransaction() error {
+	return st.rules.Hooks().CanExecuteTransaction(st.msg.From, st.msg.To, st.state)
+}
+
+// distributeFees is a convenience wrapper for calling the
+// [params.RulesHooks.DistributeFees] hook.
+func (st *StateTransition) distributeFees(effectiveTip *uint256.Int) {
 	bCtx := st.evm.Context
-	rules := st.evm.ChainConfig().Rules(bCtx.BlockNumber, bCtx.Random != nil, bCtx.Time)
-	return rules.Hooks().CanExecuteTransaction(st.msg.From, st.msg.To, st.state)
+	fees := &libevm.TransactionFees{
+		Coinbase:     bCtx.Coinbase,
+		GasUsed:      st.gasUsed(),
+		EffectiveTip: effectiveTip,
+		BlobGasUsed:  st.blobGasUsed(),
+	}
+	if bCtx.BaseFee != nil {
+		fees.BaseFee = uint256.MustFromBig(bCtx.BaseFee)
+	}
+	if bCtx.BlobBaseFee != nil {
+		fees.BlobBaseFee = uint256.MustFromBig(bCtx.BlobBaseFee)
+	}
+	if t, ok := st.nativeTransferrer(); ok {
+		fees.NativeTransferrer = t
+	}
+	st.rules.Hooks().DistributeFees(st.state, fees)
+}
+
+// MessageIntrinsicGas returns the intrinsic gas of the message, as computed by
+// [IntrinsicGas] and then modified by the [params.RulesHooks.IntrinsicGas]
+// hook.
+func MessageIntrinsicGas(rules params.Rules, msg *Message) (uint64, error) {
+	gas, err := IntrinsicGas(msg.Data, msg.AccessList, msg.To == nil, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+	if err != nil {
+		return 0, err
+	}
+	return ApplyIntrinsicGasHook(rules, msg, gas)
+}
+
+// ApplyIntrinsicGasHook is a convenience wrapper for calling the
+// [params.RulesHooks.IntrinsicGas] hook, for callers that have already
+// computed the default intrinsic gas with [IntrinsicGas].
+func ApplyIntrinsicGasHook(rules params.Rules, msg *Message, intrinsic uint64) (uint64, error) {
+	return rules.Hooks().IntrinsicGas(msg.libevmMessage(), intrinsic)
+}
+
+func (msg *Message) libevmMessage() *libevm.Message {
+	return &libevm.Message{
+		From:     msg.From,
+		To:       msg.To,
+		Value:    msg.Value,
+		GasLimit: msg.GasLimit,
+		Data:     msg.Data,
+	}
+}
+
+// gasRefund is a convenience wrapper for calling the
+// [params.RulesHooks.GasRefund] hook.
+func (st *StateTransition) gasRefund(refundQuotient uint64) uint64 {
+	used := st.gasUsed()
+	refund := st.rules.Hooks().GasRefund(used, st.state.GetRefund(), refundQuotient)
+	if refund > used {
+		refund = used
+	}
+	return refund
+}
+
+// nativeTransferrer is a convenience wrapper for calling the
+// [params.RulesHooks.TransferOverride] hook for the native asset.
+func (st *StateTransition) nativeTransferrer() (libevm.Transferrer, bool) {
+	return st.rules.Hooks().TransferOverride(libevm.NativeAsset)
+}
+
+// checkGasFunds returns an [ErrInsufficientFunds] error if the sender can't pay
+// `want` of the native asset for gas.
+func (st *StateTransition) checkGasFunds(want *uint256.Int) error {
+	from := st.msg.From
+	if t, ok := st.nativeTransferrer(); ok {
+		if !t.CanTransfer(st.state, from, want) {
+			return fmt.Errorf("%w: address %v want %v", ErrInsufficientFunds, from.Hex(), want)
+		}
+		return nil
+	}
+	if have := st.state.GetBalance(from); have.Cmp(want) < 0 {
+		return fmt.Errorf("%w: address %v have %v want %v", ErrInsufficientFunds, from.Hex(), have, want)
+	}
+	return nil
+}
+
+// debitGas debits the sender with the purchase price of gas.
+func (st *StateTransition) debitGas(amount *uint256.Int) {
+	if t, ok := st.nativeTransferrer(); ok {
+		t.Debit(st.state, st.msg.From, amount)
+		return
+	}
+	st.state.SubBalance(st.msg.From, amount)
+}
+
+// creditGas credits the sender with the refund for unused gas.
+func (st *StateTransition) creditGas(amount *uint256.Int) {
+	if t, ok := st.nativeTransferrer(); ok {
+		t.Credit(st.state, st.msg.From, amount)
+		return
+	}
+	st.state.AddBalance(st.msg.From, amount)
+}
+
+// canTransferValue reports whether the sender can transfer the message's value,
+// equivalent to [vm.EVM] behaviour.
+func (st *StateTransition) canTransferValue(value *uint256.Int) bool {
+	if t, ok := st.nativeTransferrer(); ok {
+		return t.CanTransfer(st.state, st.msg.From, value)
+	}
+	return st.evm.Context.CanTransfer(st.state, st.msg.From, value)
+}
+
+// ApplyMessageContext is equivalent to [ApplyMessage] except that execution
+// observes the cancellation and deadline of ctx; see
+// [vm.EVM.SetExecutionContext]. If execution is aborted because ctx is done,
+// the returned error [errors.Is] [vm.ErrExecutionAborted], even if the
+// outermost call frame completed, as nested frames may have been aborted. The
+// [ExecutionResult] is still returned, but MUST NOT be relied upon. Once
+// ApplyMessageContext returns, the EVM is no longer observing ctx so MAY be
+// reused, but only if execution wasn't aborted.
+func ApplyMessageContext(ctx context.Context, evm *vm.EVM, msg *Message, gp *GasPool) (*ExecutionResult, error) {
+	evm.SetExecutionContext(ctx)
+	res, err := ApplyMessage(evm, msg, gp)
+	// Observation of ctx MUST be stopped before it is checked, otherwise it
+	// could be done, and the EVM cancelled, after the check. This also allows
+	// the EVM to be reused if ctx wasn't done.
+	evm.SetExecutionContext(nil)
+
+	if err != nil {
+		return res, err
+	}
+	if ctx.Err() != nil {
+		return res, fmt.Errorf("%w: %w", vm.ErrExecutionAborted, context.Cause(ctx))
+	}
+	return res, nil
 }
@@ -16,16 +16,28 @@
 package core_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"testing"
+	"time"
 
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/libevm"
 	"github.com/ethereum/go-ethereum/libevm/ethtest"
 	"github.com/ethereum/go-ethereum/libevm/hookstest"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestCanExecuteTransaction(t *testing.T) {
@@ -54,3 +66,513 @@ func TestCanExecuteTransaction(t *testing.T) {
 	_, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(30e6))
 	require.EqualError(t, err, makeErr(msg.From, msg.To, value).Error())
 }
+
+// flatFeeTx is a [types.CustomTxData] that always pays the same gas price,
+// irrespective of the base fee.
+type flatFeeTx struct {
+	ChainId   *big.Int
+	Nonce_    uint64
+	Price     *big.Int
+	GasLimit  uint64
+	Recipient *common.Address `rlp:"nil"`
+	V, R, S   *big.Int
+}
+
+func (tx *flatFeeTx) Copy() types.CustomTxData {
+	cp := *tx
+	return &cp
+}
+
+func (tx *flatFeeTx) ChainID() *big.Int            { return tx.ChainId }
+func (tx *flatFeeTx) AccessList() types.AccessList { return nil }
+func (tx *flatFeeTx) Data() []byte                 { return nil }
+func (tx *flatFeeTx) Gas() uint64                  { return tx.GasLimit }
+func (tx *flatFeeTx) GasPrice() *big.Int           { return tx.Price }
+func (tx *flatFeeTx) GasTipCap() *big.Int          { return new(big.Int) }
+func (tx *flatFeeTx) GasFeeCap() *big.Int          { return tx.Price }
+func (tx *flatFeeTx) Value() *big.Int              { return new(big.Int) }
+func (tx *flatFeeTx) Nonce() uint64                { return tx.Nonce_ }
+func (tx *flatFeeTx) To() *common.Address          { return tx.Recipient }
+
+func (tx *flatFeeTx) RawSignatureValues() (v, r, s *big.Int) {
+	return tx.V, tx.R, tx.S
+}
+
+func (tx *flatFeeTx) SetSignatureValues(chainID, v, r, s *big.Int) {
+	tx.ChainId, tx.V, tx.R, tx.S = chainID, v, r, s
+}
+
+func (tx *flatFeeTx) EffectiveGasPrice(dst, _ *big.Int) *big.Int {
+	return dst.Set(tx.Price)
+}
+
+func (tx *flatFeeTx) SigHash(chainID *big.Int) common.Hash {
+	return crypto.Keccak256Hash([]byte{0x42}, chainID.Bytes(), tx.Price.Bytes())
+}
+
+func TestTransactionToMessageWithCustomTxType(t *testing.T) {
+	types.TestOnlyClearRegisteredTxTypes()
+	t.Cleanup(types.TestOnlyClearRegisteredTxTypes)
+	flatFee := types.RegisterTxType[flatFeeTx](0x42)
+
+	rng := ethtest.NewPseudoRand(42)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err, "crypto.GenerateKey()")
+	chainID := big.NewInt(1)
+	signer := types.LatestSignerForChainID(chainID)
+
+	price := big.NewInt(1e9)
+	tx, err := types.SignTx(flatFee.NewTx(&flatFeeTx{
+		ChainId:   chainID,
+		Nonce_:    rng.Uint64(),
+		Price:     price,
+		GasLimit:  21_000,
+		Recipient: rng.AddressPtr(),
+	}), signer, key)
+	require.NoError(t, err, "types.SignTx()")
+
+	// With a zero tip, the default effective gas price would be the base fee,
+	// capped at the price.
+	for _, baseFee := range []*big.Int{big.NewInt(1), big.NewInt(1e8)} {
+		msg, err := core.TransactionToMessage(tx, signer, baseFee)
+		require.NoErrorf(t, err, "core.TransactionToMessage(..., baseFee = %d)", baseFee)
+		assert.Equalf(t, price, msg.GasPrice, "%T.GasPrice with base fee %d", msg, baseFee)
+		assert.Equalf(t, crypto.PubkeyToAddress(key.PublicKey), msg.From, "%T.From", msg)
+		assert.Equalf(t, tx.To(), msg.To, "%T.To", msg)
+	}
+}
+
+func TestDistributeFees(t *testing.T) {
+	rng := ethtest.NewPseudoRand(1618)
+	coinbase := rng.Address()
+	treasury := rng.Address()
+	from := rng.Address()
+
+	const (
+		gasUsed   = params.TxGas
+		baseFee   = 100
+		tip       = 7
+		feeCap    = baseFee + 2*tip
+		startFund = 1e18
+	)
+
+	tests := []struct {
+		name  string
+		hooks *hookstest.Stub
+		// want maps addresses to their expected balances
+		want map[common.Address]uint64
+	}{
+		{
+			name:  "default",
+			hooks: &hookstest.Stub{},
+			want: map[common.Address]uint64{
+				coinbase: gasUsed * tip,
+				treasury: 0,
+				from:     startFund - gasUsed*(baseFee+tip),
+			},
+		},
+		{
+			name: "base_fee_to_treasury",
+			hooks: &hookstest.Stub{
+				DistributeFeesFn: func(db libevm.StateDB, f *libevm.TransactionFees) {
+					assert.Equal(t, coinbase, f.Coinbase, "Coinbase")
+					assert.Equal(t, uint64(gasUsed), f.GasUsed, "GasUsed")
+					assert.Equal(t, uint256.NewInt(tip), f.EffectiveTip, "EffectiveTip")
+					assert.Equal(t, uint256.NewInt(baseFee), f.BaseFee, "BaseFee")
+
+					base := new(uint256.Int).Mul(f.BaseFee, uint256.NewInt(f.GasUsed))
+					db.AddBalance(treasury, base)
+					// The tip is halved and split between the coinbase and the
+					// treasury.
+					half := new(uint256.Int).Mul(f.EffectiveTip, uint256.NewInt(f.GasUsed/2))
+					db.AddBalance(coinbase, half)
+					db.AddBalance(treasury, half)
+				},
+			},
+			want: map[common.Address]uint64{
+				coinbase: gasUsed / 2 * tip,
+				treasury: gasUsed*baseFee + gasUsed/2*tip,
+				from:     startFund - gasUsed*(baseFee+tip),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.hooks.Register(t)
+
+			state, evm := ethtest.NewZeroEVM(t,
+				ethtest.WithChainConfig(params.TestChainConfig),
+				ethtest.WithBlockContext(vm.BlockContext{
+					CanTransfer: core.CanTransfer,
+					Transfer:    core.Transfer,
+					Coinbase:    coinbase,
+					BlockNumber: big.NewInt(1),
+					BaseFee:     big.NewInt(baseFee),
+				}),
+			)
+			state.SetBalance(from, uint256.NewInt(startFund))
+
+			msg := &core.Message{
+				From:      from,
+				To:        rng.AddressPtr(),
+				Value:     new(big.Int),
+				GasLimit:  gasUsed,
+				GasPrice:  big.NewInt(baseFee + tip),
+				GasFeeCap: big.NewInt(feeCap),
+				GasTipCap: big.NewInt(tip),
+			}
+			res, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(30e6))
+			require.NoError(t, err, "core.ApplyMessage()")
+			require.NoError(t, res.Err, "core.ApplyMessage() execution error")
+			require.Equal(t, uint64(gasUsed), res.UsedGas, "gas used")
+
+			for addr, want := range tt.want {
+				assert.Equalf(t, uint256.NewInt(want), state.GetBalance(addr), "balance of %v", addr)
+			}
+		})
+	}
+}
+
+// newLondonEVM returns a new EVM with all forks up to and including London
+// active, suitable for calling [core.ApplyMessage].
+func newLondonEVM(t *testing.T) (*state.StateDB, *vm.EVM) {
+	t.Helper()
+	return ethtest.NewZeroEVM(t,
+		ethtest.WithChainConfig(params.TestChainConfig),
+		ethtest.WithBlockContext(vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: big.NewInt(0),
+			BaseFee:     new(big.Int),
+		}),
+	)
+}
+
+func TestIntrinsicGasHook(t *testing.T) {
+	rng := ethtest.NewPseudoRand(271828)
+	to := rng.AddressPtr()
+	data := []byte{1, 2, 3}
+
+	const extra = 1000
+	hooks := &hookstest.Stub{
+		IntrinsicGasFn: func(msg *libevm.Message, intrinsic uint64) (uint64, error) {
+			if msg.To == nil {
+				return 0, errors.New("contract creation disabled")
+			}
+			return intrinsic + uint64(len(msg.Data))*extra, nil
+		},
+	}
+	hooks.Register(t)
+
+	rules := params.TestChainConfig.Rules(big.NewInt(0), false, 0)
+	native, err := core.IntrinsicGas(data, nil, false, true, true, true)
+	require.NoError(t, err, "core.IntrinsicGas()")
+	want := native + uint64(len(data))*extra
+
+	msg := &core.Message{
+		To:    to,
+		Value: new(big.Int),
+		Data:  data,
+	}
+	got, err := core.MessageIntrinsicGas(rules, msg)
+	require.NoError(t, err, "core.MessageIntrinsicGas()")
+	require.Equal(t, want, got, "core.MessageIntrinsicGas()")
+
+	tests := []struct {
+		name     string
+		to       *common.Address
+		gasLimit uint64
+		wantErr  error
+	}{
+		{
+			name:     "sufficient",
+			to:       to,
+			gasLimit: want,
+		},
+		{
+			name:     "native_only",
+			to:       to,
+			gasLimit: native,
+			wantErr:  core.ErrIntrinsicGas,
+		},
+		{
+			name:     "hook_error",
+			gasLimit: 1e6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, evm := newLondonEVM(t)
+			msg := &core.Message{
+				From:      rng.Address(),
+				To:        tt.to,
+				Value:     new(big.Int),
+				GasLimit:  tt.gasLimit,
+				GasPrice:  new(big.Int),
+				GasFeeCap: new(big.Int),
+				GasTipCap: new(big.Int),
+				Data:      data,
+			}
+			res, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(30e6))
+			switch {
+			case tt.to == nil:
+				require.EqualError(t, err, "contract creation disabled", "core.ApplyMessage()")
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr, "core.ApplyMessage()")
+			default:
+				require.NoError(t, err, "core.ApplyMessage()")
+				assert.Equal(t, want, res.UsedGas, "gas used")
+			}
+		})
+	}
+}
+
+func TestGasRefundHook(t *testing.T) {
+	rng := ethtest.NewPseudoRand(314159)
+
+	const (
+		gasLimit      = 1e6
+		refundCounter = 5000
+		refund        = 1234
+	)
+	var gotUsed, gotCounter, gotQuotient uint64
+	hooks := &hookstest.Stub{
+		GasRefundFn: func(gasUsed, counter, quotient uint64) uint64 {
+			gotUsed, gotCounter, gotQuotient = gasUsed, counter, quotient
+			return refund
+		},
+	}
+	hooks.Register(t)
+
+	state, evm := newLondonEVM(t)
+	state.AddRefund(refundCounter)
+	msg := &core.Message{
+		From:      rng.Address(),
+		To:        rng.AddressPtr(),
+		Value:     new(big.Int),
+		GasLimit:  gasLimit,
+		GasPrice:  new(big.Int),
+		GasFeeCap: new(big.Int),
+		GasTipCap: new(big.Int),
+	}
+	res, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(30e6))
+	require.NoError(t, err, "core.ApplyMessage()")
+
+	assert.Equal(t, uint64(params.TxGas), gotUsed, "gas used passed to hook")
+	assert.Equal(t, uint64(refundCounter), gotCounter, "refund counter passed to hook")
+	assert.Equal(t, uint64(params.RefundQuotientEIP3529), gotQuotient, "refund quotient passed to hook")
+	assert.Equal(t, uint64(refund), res.RefundedGas, "refunded gas")
+	assert.Equal(t, params.TxGas-refund, res.UsedGas, "gas used after refund")
+}
+
+// ledger is a [libevm.Transferrer] that records balances in the storage of a
+// single account, keyed by the address of the balance holder.
+type ledger common.Address
+
+func (l ledger) Balance(s libevm.StateReader, addr common.Address) *uint256.Int {
+	v := s.GetState(common.Address(l), common.BytesToHash(addr.Bytes()))
+	return new(uint256.Int).SetBytes(v[:])
+}
+
+func (l ledger) set(s libevm.StateDB, addr common.Address, bal *uint256.Int) {
+	s.SetState(common.Address(l), common.BytesToHash(addr.Bytes()), bal.Bytes32())
+}
+
+func (l ledger) CanTransfer(s libevm.StateReader, from common.Address, amount *uint256.Int) bool {
+	return l.Balance(s, from).Cmp(amount) >= 0
+}
+
+func (l ledger) Debit(s libevm.StateDB, from common.Address, amount *uint256.Int) {
+	l.set(s, from, new(uint256.Int).Sub(l.Balance(s, from), amount))
+}
+
+func (l ledger) Credit(s libevm.StateDB, to common.Address, amount *uint256.Int) {
+	l.set(s, to, new(uint256.Int).Add(l.Balance(s, to), amount))
+}
+
+func TestNativeTransferOverride(t *testing.T) {
+	rng := ethtest.NewPseudoRand(5772)
+	from := rng.Address()
+	to := rng.Address()
+	coinbase := rng.Address()
+	native := ledger(rng.Address())
+
+	hooks := &hookstest.Stub{
+		TransferOverrides: map[libevm.AssetID]libevm.Transferrer{
+			libevm.NativeAsset: native,
+		},
+	}
+	hooks.Register(t)
+
+	const (
+		gasLimit = 2 * params.TxGas // for testing refunds
+		gasPrice = 3
+		value    = 1000
+		upfront  = gasLimit*gasPrice + value
+		cost     = params.TxGas*gasPrice + value
+		tip      = params.TxGas * gasPrice // as the base fee is zero
+	)
+
+	tests := []struct {
+		name    string
+		fund    uint64
+		wantErr error
+	}{
+		{
+			name: "sufficient",
+			fund: upfront,
+		},
+		{
+			name:    "insufficient",
+			fund:    upfront - 1,
+			wantErr: core.ErrInsufficientFunds,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, evm := newLondonEVM(t)
+			evm.Context.Coinbase = coinbase
+			state.SetNonce(common.Address(native), 1)
+			native.set(state, from, uint256.NewInt(tt.fund))
+			// The native balance MUST be ignored in favour of the ledger.
+			unused := uint256.NewInt(1e18)
+			state.SetBalance(from, unused)
+
+			msg := &core.Message{
+				From:      from,
+				To:        &to,
+				Value:     big.NewInt(value),
+				GasLimit:  gasLimit,
+				GasPrice:  big.NewInt(gasPrice),
+				GasFeeCap: big.NewInt(gasPrice),
+				GasTipCap: big.NewInt(gasPrice),
+			}
+			_, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(30e6))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr, "core.ApplyMessage()")
+				return
+			}
+			require.NoError(t, err, "core.ApplyMessage()")
+
+			assert.Equal(t, uint256.NewInt(tt.fund-cost), native.Balance(state, from), "ledger balance of sender")
+			assert.Equal(t, uint256.NewInt(value), native.Balance(state, to), "ledger balance of recipient")
+			assert.Equal(t, uint256.NewInt(tip), native.Balance(state, coinbase), "ledger balance of coinbase; i.e. tip credited via Transferrer")
+			assert.Equal(t, unused, state.GetBalance(from), "native balance of sender")
+			assert.True(t, state.GetBalance(to).IsZero(), "native balance of recipient is zero")
+			assert.True(t, state.GetBalance(coinbase).IsZero(), "native balance of coinbase is zero")
+
+			total := new(uint256.Int)
+			for _, addr := range []common.Address{from, to, coinbase} {
+				total.Add(total, native.Balance(state, addr))
+			}
+			assert.Equal(t, uint256.NewInt(tt.fund), total, "sum of ledger balances; i.e. asset conserved")
+		})
+	}
+}
+
+func TestApplyMessageContext(t *testing.T) {
+	rng := ethtest.NewPseudoRand(1618)
+	contract := rng.Address()
+	// JUMPDEST; PUSH1 0; JUMP; i.e. an infinite loop.
+	loop := []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0, byte(vm.JUMP)}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name        string
+		ctx         func() (context.Context, context.CancelFunc)
+		gasLimit    uint64
+		wantErr     []error
+		wantExecErr error
+	}{
+		{
+			name: "no cancellation",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.Background(), func() {}
+			},
+			gasLimit:    1e5,
+			wantExecErr: vm.ErrOutOfGas,
+		},
+		{
+			name: "cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return cancelled, func() {}
+			},
+			gasLimit: math.MaxUint64 / 2,
+			wantErr:  []error{vm.ErrExecutionAborted, context.Canceled},
+		},
+		{
+			name: "deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			gasLimit: math.MaxUint64 / 2,
+			wantErr:  []error{vm.ErrExecutionAborted, context.DeadlineExceeded},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, evm := ethtest.NewZeroEVM(t)
+			state.SetCode(contract, loop)
+
+			msg := &core.Message{
+				From:      rng.Address(),
+				To:        &contract,
+				Value:     new(big.Int),
+				GasLimit:  tt.gasLimit,
+				GasPrice:  new(big.Int),
+				GasFeeCap: new(big.Int),
+				GasTipCap: new(big.Int),
+			}
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			res, err := core.ApplyMessageContext(ctx, evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+			for _, want := range tt.wantErr {
+				require.ErrorIs(t, err, want, "core.ApplyMessageContext()")
+			}
+			if len(tt.wantErr) > 0 {
+				return
+			}
+			require.NoError(t, err, "core.ApplyMessageContext()")
+			require.ErrorIs(t, res.Err, tt.wantExecErr, "core.ApplyMessageContext() execution error")
+		})
+	}
+}
+
+func TestApplyMessageContextEVMReuse(t *testing.T) {
+	rng := ethtest.NewPseudoRand(2236)
+	contract := rng.Address()
+
+	state, evm := ethtest.NewZeroEVM(t)
+	// PUSH1 0; PUSH1 0; RETURN
+	state.SetCode(contract, []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.RETURN)})
+
+	msg := &core.Message{
+		From:      rng.Address(),
+		To:        &contract,
+		Value:     new(big.Int),
+		GasLimit:  1e5,
+		GasPrice:  new(big.Int),
+		GasFeeCap: new(big.Int),
+		GasTipCap: new(big.Int),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err := core.ApplyMessageContext(ctx, evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	require.NoError(t, err, "core.ApplyMessageContext()")
+	require.NoError(t, res.Err, "core.ApplyMessageContext() execution error")
+
+	cancel()
+	require.Never(t, evm.Cancelled, 50*time.Millisecond, time.Millisecond, "%T.Cancelled() after cancelling context of completed execution", evm)
+
+	msg.Nonce = state.GetNonce(msg.From)
+	res, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	require.NoError(t, err, "core.ApplyMessage() reusing EVM")
+	require.NoError(t, res.Err, "core.ApplyMessage() reusing EVM; execution error")
+}
@@ -668,7 +668,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 	// Ensure that there's no over-draft, this is expected to happen when some
 	// transactions get included without publishing on the network
 	var (
-		balance = p.state.GetBalance(addr)
+		balance = txpool.NativeBalance(p.headRules(), p.state, addr) // libevm: was p.state.GetBalance(addr)
 		spent   = p.spent[addr]
 	)
 	if spent.Cmp(balance) > 0 {
@@ -799,8 +799,8 @@ func (p *BlobPool) Reset(oldHead, newHead *types.Header) {
 
 	// Run the reorg between the old and new head and figure out which accounts
 	// need to be rechecked and which transactions need to be readded
+	var adds []*types.Transaction // libevm: hoisted to announce after evictBlockedTxs()
 	if reinject, inclusions := p.reorg(oldHead, newHead); reinject != nil {
-		var adds []*types.Transaction
 		for addr, txs := range reinject {
 			// Blindly push all the lost transactions back into the pool
 			for _, tx := range txs {
@@ -812,10 +812,14 @@ func (p *BlobPool) Reset(oldHead, newHead *types.Header) {
 			// invalidated ones
 			p.recheck(addr, inclusions)
 		}
-		if len(adds) > 0 {
-			p.insertFeed.Send(core.NewTxsEvent{Txs: adds})
-		}
 	}
+	// libevm: blocked transactions MUST be evicted, and not announced, before
+	// any reinjected transactions are.
+	p.evictBlockedTxs()
+	if adds = p.stillPooled(adds); len(adds) > 0 {
+		p.insertFeed.Send(core.NewTxsEvent{Txs: adds})
+	}
+
 	// Flush out any blobs from limbo that are older than the latest finality
 	if p.chain.Config().IsCancun(p.head.Number, p.head.Time) {
 		p.limbo.finalize(p.chain.CurrentFinalBlock())
@@ -1120,6 +1124,7 @@ func (p *BlobPool) validateTx(tx *types.Transaction) error {
 			}
 			return nil
 		},
+		Rules: p.headRules(), // libevm
 	}
 	if err := txpool.ValidateTransactionWithState(tx, p.signer, stateOpts); err != nil {
 		return err
@@ -0,0 +1,122 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package blobpool
+
+import (
+	"container/heap"
+
+	"github.com/holiman/uint256"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// headRules returns the rules in effect at the pool's current head.
+func (p *BlobPool) headRules() *params.Rules {
+	rules := txpool.HeadRules(p.chain.Config(), p.head)
+	return &rules
+}
+
+// evictBlockedTxs drops all pooled transactions that are no longer admitted by
+// [txpool.ValidateTransactionAdmission], e.g. because of an allowlist change
+// in the new head's state. As nonce gaps are not permitted, all of an
+// account's transactions following a blocked one are also dropped. It MUST be
+// called with the pool lock held, after the pool's state has been reset.
+//
+// Re-evaluation requires loading every pooled transaction from the store, so
+// is skipped unless requested by [txpool.Hooks.ReevaluateOnReset].
+func (p *BlobPool) evictBlockedTxs() {
+	rules := p.headRules()
+	if !txpool.ReevaluateOnReset(*rules) {
+		return
+	}
+	for addr, txs := range p.index {
+		for i, meta := range txs {
+			tx, err := p.loadTx(meta.id)
+			if err != nil {
+				log.Error("Failed to load blob transaction for admission check", "from", addr, "id", meta.id, "err", err)
+				continue
+			}
+			if err := txpool.ValidateTransactionAdmission(*rules, tx, addr, p.state); err != nil {
+				log.Trace("Dropping blocked blob transactions", "from", addr, "nonce", meta.nonce, "err", err)
+				p.dropFrom(addr, i)
+				break
+			}
+		}
+	}
+}
+
+// stillPooled returns the subset of `txs` that remain in the pool, e.g. after
+// [BlobPool.evictBlockedTxs]. The input slice is modified in place.
+func (p *BlobPool) stillPooled(txs []*types.Transaction) []*types.Transaction {
+	kept := txs[:0]
+	for _, tx := range txs {
+		if _, ok := p.lookup[tx.Hash()]; ok {
+			kept = append(kept, tx)
+		}
+	}
+	return kept
+}
+
+func (p *BlobPool) loadTx(id uint64) (*types.Transaction, error) {
+	data, err := p.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(data, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// dropFrom drops the account's transactions from index `i` onwards.
+func (p *BlobPool) dropFrom(addr common.Address, i int) {
+	var (
+		txs    = p.index[addr]
+		ids    []uint64
+		nonces []uint64
+	)
+	for _, tx := range txs[i:] {
+		ids = append(ids, tx.id)
+		nonces = append(nonces, tx.nonce)
+
+		p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], tx.costCap)
+		p.stored -= uint64(tx.size)
+		delete(p.lookup, tx.hash)
+	}
+	log.Trace("Dropped blocked blob transactions", "from", addr, "drop", nonces, "ids", ids)
+
+	for _, id := range ids {
+		if err := p.store.Delete(id); err != nil {
+			log.Error("Failed to delete blob transaction", "from", addr, "id", id, "err", err)
+		}
+	}
+	if i == 0 {
+		delete(p.index, addr)
+		delete(p.spent, addr)
+		heap.Remove(p.evict, p.evict.index[addr])
+		p.reserve(addr, false)
+		return
+	}
+	p.index[addr] = txs[:i]
+	heap.Fix(p.evict, p.evict.index[addr])
+}
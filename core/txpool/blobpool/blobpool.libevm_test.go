@@ -0,0 +1,123 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package blobpool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// blocklistHooks block transactions with hashes recorded in the state of the
+// `blocklist` address, re-evaluating pooled transactions only if `reevaluate`
+// is true.
+type blocklistHooks struct {
+	txpool.NOOPHooks
+	blocklist  common.Address
+	reevaluate bool
+}
+
+var errBlocked = errors.New("blocked")
+
+func (h *blocklistHooks) ReevaluateOnReset(params.Rules) bool {
+	return h.reevaluate
+}
+
+func (h *blocklistHooks) CanAdmitTransaction(_ params.Rules, tx *types.Transaction, _ common.Address, sr libevm.StateReader) error {
+	if sr.GetState(h.blocklist, tx.Hash()) != (common.Hash{}) {
+		return errBlocked
+	}
+	return nil
+}
+
+func TestEvictBlockedTxs(t *testing.T) {
+	hooks := &blocklistHooks{
+		blocklist: common.Address{'b', 'l', 'o', 'c', 'k'},
+	}
+	txpool.TestOnlyClearRegisteredHooks()
+	t.Cleanup(txpool.TestOnlyClearRegisteredHooks)
+	txpool.RegisterHooks(hooks)
+
+	const (
+		basefee = 1050
+		blobfee = 105
+	)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err, "state.New()")
+	chain := &testBlockChain{
+		config:  testChainConfig,
+		basefee: uint256.NewInt(basefee),
+		blobfee: uint256.NewInt(blobfee),
+		statedb: statedb,
+	}
+	pool := New(Config{Datadir: t.TempDir()}, chain)
+	require.NoError(t, pool.Init(1, chain.CurrentBlock(), makeAddressReserver()), "Init()")
+	defer pool.Close()
+
+	keyA, _ := crypto.GenerateKey()
+	keyB, _ := crypto.GenerateKey()
+	addrA := crypto.PubkeyToAddress(keyA.PublicKey)
+	addrB := crypto.PubkeyToAddress(keyB.PublicKey)
+	statedb.AddBalance(addrA, uint256.NewInt(params.Ether))
+	statedb.AddBalance(addrB, uint256.NewInt(params.Ether))
+
+	var txsA []*types.Transaction
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		txsA = append(txsA, makeTx(nonce, 10, basefee+10, blobfee, keyA))
+	}
+	txB := makeTx(0, 10, basefee+10, blobfee, keyB)
+	for _, tx := range append(txsA, txB) {
+		require.NoErrorf(t, pool.add(tx), "add(%#x)", tx.Hash())
+	}
+
+	// Blocking B's first transaction MUST also drop its second.
+	require.NoError(t, pool.add(makeTx(1, 10, basefee+10, blobfee, keyB)), "add(B, nonce 1)")
+
+	block := func(tx *types.Transaction) {
+		statedb.SetState(hooks.blocklist, tx.Hash(), common.Hash{1})
+	}
+	block(txsA[1])
+	block(txB)
+
+	pool.Reset(nil, chain.CurrentBlock())
+	require.Len(t, pool.index[addrA], 3, "transactions retained without opting in to re-evaluation")
+	require.Len(t, pool.index[addrB], 2, "transactions retained without opting in to re-evaluation")
+
+	hooks.reevaluate = true
+	pool.Reset(nil, chain.CurrentBlock())
+
+	assert.Len(t, pool.index[addrA], 1, "transactions from partially blocked sender; all after blocked nonce MUST be dropped")
+	assert.NotContains(t, pool.index, addrB, "fully blocked sender")
+	assert.True(t, pool.Has(txsA[0].Hash()), "unblocked transaction retained")
+	verifyPoolInternals(t, pool)
+
+	err = pool.add(txB)
+	assert.ErrorIs(t, err, txpool.ErrTxBlocked, "add() of blocked transaction")
+	assert.ErrorIs(t, err, errBlocked, "add() of blocked transaction")
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// headRules returns the rules in effect at the pool's current head.
+func (pool *LegacyPool) headRules() *params.Rules {
+	rules := txpool.HeadRules(pool.chainconfig, pool.currentHead.Load())
+	return &rules
+}
+
+// evictBlockedTxs removes all pending and queued transactions that are no
+// longer admitted by [txpool.ValidateTransactionAdmission], e.g. because of an
+// allowlist change in the new head's state. It MUST be called with the pool
+// lock held, after the pool's state has been reset, and is a no-op unless
+// requested by [txpool.Hooks.ReevaluateOnReset].
+func (pool *LegacyPool) evictBlockedTxs() {
+	rules := pool.headRules()
+	if !txpool.ReevaluateOnReset(*rules) {
+		return
+	}
+	var blocked []common.Hash
+	check := func(addr common.Address, txs types.Transactions) {
+		for _, tx := range txs {
+			if err := txpool.ValidateTransactionAdmission(*rules, tx, addr, pool.currentState); err != nil {
+				log.Trace("Evicting blocked transaction", "hash", tx.Hash(), "err", err)
+				blocked = append(blocked, tx.Hash())
+			}
+		}
+	}
+	for addr, list := range pool.pending {
+		check(addr, list.Flatten())
+	}
+	for addr, list := range pool.queue {
+		check(addr, list.Flatten())
+	}
+	for _, hash := range blocked {
+		pool.removeTx(hash, true, true)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm"
+	"github.com/ethereum/go-ethereum/libevm/hookstest"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// admissionHooks block all transactions with a gas price of
+// `blockedGasPrice`, and request re-evaluation of pooled transactions on every
+// reset.
+type admissionHooks struct {
+	txpool.NOOPHooks
+}
+
+func (admissionHooks) ReevaluateOnReset(params.Rules) bool { return true }
+
+const blockedGasPrice = 2
+
+var errBlockedGasPrice = errors.New("blocked gas price")
+
+func (admissionHooks) CanAdmitTransaction(_ params.Rules, tx *types.Transaction, _ common.Address, _ libevm.StateReader) error {
+	if tx.GasPrice().Cmp(big.NewInt(blockedGasPrice)) == 0 {
+		return errBlockedGasPrice
+	}
+	return nil
+}
+
+func TestTransactionAdmission(t *testing.T) {
+	// The blocklist is stored in state so changes are only reflected after a
+	// pool reset.
+	blocklist := common.Address{'b', 'l', 'o', 'c', 'k'}
+	errBlockedSender := errors.New("blocked sender")
+	hooks := &hookstest.Stub{
+		CanExecuteTransactionFn: func(from common.Address, _ *common.Address, sr libevm.StateReader) error {
+			if sr.GetState(blocklist, common.BytesToHash(from.Bytes())) != (common.Hash{}) {
+				return errBlockedSender
+			}
+			return nil
+		},
+	}
+	hooks.Register(t)
+
+	txpool.TestOnlyClearRegisteredHooks()
+	t.Cleanup(txpool.TestOnlyClearRegisteredHooks)
+	txpool.RegisterHooks(admissionHooks{})
+
+	pool, keyA := setupPool()
+	defer pool.Close()
+	keyB, err := crypto.GenerateKey()
+	require.NoError(t, err, "crypto.GenerateKey()")
+	addrA := crypto.PubkeyToAddress(keyA.PublicKey)
+	addrB := crypto.PubkeyToAddress(keyB.PublicKey)
+	testAddBalance(pool, addrA, big.NewInt(params.Ether))
+	testAddBalance(pool, addrB, big.NewInt(params.Ether))
+
+	for _, tx := range []*types.Transaction{
+		transaction(0, 100000, keyA),
+		transaction(1, 100000, keyA),
+		transaction(3, 100000, keyA), // queued
+		transaction(0, 100000, keyB),
+	} {
+		require.NoErrorf(t, pool.addRemoteSync(tx), "addRemoteSync(%#x) before blocking", tx.Hash())
+	}
+	err = pool.addRemoteSync(pricedTransaction(1, 100000, big.NewInt(blockedGasPrice), keyB))
+	require.ErrorIs(t, err, txpool.ErrTxBlocked, "addRemoteSync() with blocked gas price")
+	require.ErrorIs(t, err, errBlockedGasPrice, "addRemoteSync() with blocked gas price")
+
+	pending, queued := pool.Stats()
+	require.Equal(t, 3, pending, "pending before blocking sender")
+	require.Equal(t, 1, queued, "queued before blocking sender")
+
+	pool.mu.Lock()
+	pool.currentState.SetState(blocklist, common.BytesToHash(addrA.Bytes()), common.Hash{1})
+	pool.mu.Unlock()
+	<-pool.requestReset(nil, nil)
+
+	pending, queued = pool.Stats()
+	assert.Equal(t, 1, pending, "pending after blocking sender")
+	assert.Equal(t, 0, queued, "queued after blocking sender")
+	assert.NotNil(t, pool.Get(transaction(0, 100000, keyB).Hash()), "transaction from unblocked sender retained")
+	require.NoError(t, validatePoolInternals(pool), "validatePoolInternals()")
+
+	err = pool.addRemoteSync(transaction(0, 100000, keyA))
+	assert.ErrorIs(t, err, txpool.ErrTxBlocked, "addRemoteSync() from blocked sender")
+	assert.ErrorIs(t, err, errBlockedSender, "addRemoteSync() from blocked sender")
+}
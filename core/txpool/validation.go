@@ -97,7 +97,8 @@ func ValidateTransaction(tx *types.Transaction, head *types.Header, signer types
 		return core.ErrTipAboveFeeCap
 	}
 	// Make sure the transaction is signed properly
-	if _, err := types.Sender(signer, tx); err != nil {
+	from, err := types.Sender(signer, tx)
+	if err != nil {
 		return ErrInvalidSender
 	}
 	// Ensure the transaction has more gas than the bare minimum needed to cover
@@ -106,6 +107,12 @@ func ValidateTransaction(tx *types.Transaction, head *types.Header, signer types
 	if err != nil {
 		return err
 	}
+	// libevm: extras registrants MAY modify the intrinsic gas
+	rules := HeadRules(opts.Config, head)
+	msg := &core.Message{From: from, To: tx.To(), Value: tx.Value(), GasLimit: tx.Gas(), Data: tx.Data(), AccessList: tx.AccessList()}
+	if intrGas, err = core.ApplyIntrinsicGasHook(rules, msg, intrGas); err != nil {
+		return err
+	}
 	if tx.Gas() < intrGas {
 		return fmt.Errorf("%w: gas %v, minimum needed %v", core.ErrIntrinsicGas, tx.Gas(), intrGas)
 	}
@@ -191,6 +198,11 @@ type ValidationOptionsWithState struct {
 	// ExistingCost is a mandatory callback to retrieve an already pooled
 	// transaction's cost with the given nonce to check for overdrafts.
 	ExistingCost func(addr common.Address, nonce uint64) *big.Int
+
+	// libevm: Rules are the rules in effect at the pool's current head, used
+	// to check transaction admission with [ValidateTransactionAdmission]. If
+	// nil then admission isn't checked.
+	Rules *params.Rules
 }
 
 // ValidateTransactionWithState is a helper method to check whether a transaction
@@ -205,6 +217,12 @@ func ValidateTransactionWithState(tx *types.Transaction, signer types.Signer, op
 		log.Error("Transaction sender recovery failed", "err", err)
 		return err
 	}
+	// libevm: ensure the transaction is allowed by extras registrants
+	if opts.Rules != nil {
+		if err := ValidateTransactionAdmission(*opts.Rules, tx, from, opts.State); err != nil {
+			return fmt.Errorf("%w: %w", ErrTxBlocked, err)
+		}
+	}
 	next := opts.State.GetNonce(from)
 	if next > tx.Nonce() {
 		return fmt.Errorf("%w: next nonce %v, tx nonce %v", core.ErrNonceTooLow, next, tx.Nonce())
@@ -218,7 +236,7 @@ func ValidateTransactionWithState(tx *types.Transaction, signer types.Signer, op
 	}
 	// Ensure the transactor has enough funds to cover the transaction costs
 	var (
-		balance = opts.State.GetBalance(from).ToBig()
+		balance = NativeBalance(opts.Rules, opts.State, from).ToBig() // libevm: was opts.State.GetBalance(from)
 		cost    = tx.Cost()
 	)
 	if balance.Cmp(cost) < 0 {
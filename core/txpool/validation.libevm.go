@@ -0,0 +1,125 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+
+	"github.com/holiman/uint256"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/libevm"
+	"github.com/ethereum/go-ethereum/libevm/testonly"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// RegisterHooks registers the Hooks. It is expected to be called in an `init()`
+// function and MUST NOT be called more than once.
+func RegisterHooks(h Hooks) {
+	if registeredHooks != nil {
+		panic("already registered")
+	}
+	registeredHooks = h
+}
+
+var registeredHooks Hooks
+
+// Hooks are arbitrary configuration functions to modify default transaction
+// pool behaviour. See [RegisterHooks].
+type Hooks interface {
+	// CanAdmitTransaction is called by [ValidateTransactionWithState], after
+	// [params.RulesAllowlistHooks.CanExecuteTransaction] has allowed the
+	// transaction, and on pool resets to re-evaluate pooled transactions (see
+	// ReevaluateOnReset). A non-nil error blocks the transaction from, or
+	// evicts it from, the pool. The [libevm.StateReader] reflects the pool's
+	// current head.
+	CanAdmitTransaction(_ params.Rules, _ *types.Transaction, from common.Address, _ libevm.StateReader) error
+	// ReevaluateOnReset is called on every pool reset, with the rules in effect
+	// at the new head, and reports whether all pooled transactions must be
+	// re-evaluated with [ValidateTransactionAdmission]. Re-evaluation is costly
+	// (e.g. every blob transaction is loaded from disk) so SHOULD only be
+	// requested when a head may change the outcome, such as an allowlist change.
+	ReevaluateOnReset(params.Rules) bool
+}
+
+// NOOPHooks implements [Hooks] such that they are equivalent to no hooks
+// having been registered. Implementations that only wish to modify a subset of
+// behaviour SHOULD embed NOOPHooks.
+type NOOPHooks struct{}
+
+var _ Hooks = NOOPHooks{}
+
+// CanAdmitTransaction allows all (otherwise valid) transactions.
+func (NOOPHooks) CanAdmitTransaction(params.Rules, *types.Transaction, common.Address, libevm.StateReader) error {
+	return nil
+}
+
+// ReevaluateOnReset returns false.
+func (NOOPHooks) ReevaluateOnReset(params.Rules) bool { return false }
+
+// TestOnlyClearRegisteredHooks clears the [Hooks] previously passed to
+// [RegisterHooks]. It panics if called from a non-testing call stack.
+func TestOnlyClearRegisteredHooks() {
+	testonly.OrPanic(func() {
+		registeredHooks = nil
+	})
+}
+
+// ErrTxBlocked is returned if a transaction is blocked by
+// [ValidateTransactionAdmission]; it is wrapped alongside the blocking error.
+var ErrTxBlocked = errors.New("transaction blocked")
+
+// HeadRules returns the [params.Rules] in effect at the given head.
+func HeadRules(config *params.ChainConfig, head *types.Header) params.Rules {
+	return config.Rules(head.Number, head.Difficulty != nil && head.Difficulty.Sign() == 0, head.Time)
+}
+
+// NativeBalance returns the account's balance of the native asset, as defined
+// by the [libevm.Transferrer] returned by [params.RulesHooks.TransferOverride]
+// if the asset is overridden, otherwise the balance held in the state. Rules
+// MAY be nil, in which case the balance held in the state is returned.
+func NativeBalance(rules *params.Rules, state libevm.StateReader, addr common.Address) *uint256.Int {
+	if rules != nil {
+		if t, ok := rules.Hooks().TransferOverride(libevm.NativeAsset); ok {
+			return t.Balance(state, addr)
+		}
+	}
+	return state.GetBalance(addr)
+}
+
+// ValidateTransactionAdmission checks whether the transaction is allowed into
+// the pool by both [params.RulesAllowlistHooks.CanExecuteTransaction] and the
+// registered [Hooks], if any.
+func ValidateTransactionAdmission(rules params.Rules, tx *types.Transaction, from common.Address, state libevm.StateReader) error {
+	if err := rules.Hooks().CanExecuteTransaction(from, tx.To(), state); err != nil {
+		return err
+	}
+	if registeredHooks == nil {
+		return nil
+	}
+	return registeredHooks.CanAdmitTransaction(rules, tx, from, state)
+}
+
+// ReevaluateOnReset reports whether pools must re-evaluate all pooled
+// transactions with [ValidateTransactionAdmission] upon resetting to a head with
+// the given rules. Re-evaluation is opt-in, via [Hooks.ReevaluateOnReset], even
+// if only [params.RulesAllowlistHooks.CanExecuteTransaction] may block
+// transactions.
+func ReevaluateOnReset(rules params.Rules) bool {
+	return registeredHooks != nil && registeredHooks.ReevaluateOnReset(rules)
+}
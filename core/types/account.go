@@ -39,6 +39,10 @@ type Account struct {
 	Balance *big.Int                    `json:"balance" gencodec:"required"`
 	Nonce   uint64                      `json:"nonce,omitempty"`
 
+	// libevm: payload registered with [RegisterExtras], committed alongside
+	// the rest of the allocation. See [ExtraPayloads.SetOnGenesisAccount].
+	Extra *StateAccountExtra `json:"extra,omitempty"`
+
 	// used in tests
 	PrivateKey []byte `json:"secretKey,omitempty"`
 }
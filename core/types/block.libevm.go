@@ -0,0 +1,317 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// HeaderHooks are required for all types registered with [RegisterExtras] for
+// [Header] payloads.
+//
+// The hooks replace, in their entirety, the respective encoding and decoding
+// of the [Header] that they are attached to. They therefore have full control
+// over the RLP and JSON formats and MAY, for example, insert additional fields
+// anywhere in the encoding. Implementations that only wish to modify a subset
+// of behaviour SHOULD embed [NOOPHeaderHooks], which reproduces default geth
+// behaviour.
+type HeaderHooks interface {
+	EncodeJSON(*Header) ([]byte, error)
+	DecodeJSON(*Header, []byte) error
+	EncodeRLP(*Header, io.Writer) error
+	DecodeRLP(*Header, *rlp.Stream) error
+	// PostCopy is called at the end of [CopyHeader], on the payload of the
+	// source [Header], with the newly created copy. By default, the copy
+	// shares the same payload as the source; implementations SHOULD therefore
+	// perform a deep copy, if necessary, with [ExtraPayloads.SetOnHeader].
+	PostCopy(dst *Header)
+}
+
+// hooks returns the Header's registered HeaderHooks, if any, otherwise a
+// [*NOOPHeaderHooks] suitable for running default behaviour.
+func (h *Header) hooks() HeaderHooks {
+	if r := registeredExtras; r != nil {
+		return r.hooks.hooksFromHeader(h)
+	}
+	return new(NOOPHeaderHooks)
+}
+
+var _ interface {
+	rlp.Encoder
+	rlp.Decoder
+	json.Marshaler
+	json.Unmarshaler
+} = (*Header)(nil)
+
+// EncodeRLP implements the [rlp.Encoder] interface.
+func (h *Header) EncodeRLP(w io.Writer) error {
+	return h.hooks().EncodeRLP(h, w)
+}
+
+// DecodeRLP implements the [rlp.Decoder] interface.
+func (h *Header) DecodeRLP(s *rlp.Stream) error {
+	return h.hooks().DecodeRLP(h, s)
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (h Header) MarshalJSON() ([]byte, error) {
+	return h.hooks().EncodeJSON(&h)
+}
+
+// UnmarshalJSON implements the [json.Unmarshaler] interface.
+func (h *Header) UnmarshalJSON(b []byte) error {
+	return h.hooks().DecodeJSON(h, b)
+}
+
+// NOOPHeaderHooks implements [HeaderHooks] such that they are equivalent to
+// no type having been registered.
+type NOOPHeaderHooks struct{}
+
+var _ HeaderHooks = (*NOOPHeaderHooks)(nil)
+
+// EncodeJSON performs default JSON encoding of the [Header].
+func (*NOOPHeaderHooks) EncodeJSON(h *Header) ([]byte, error) {
+	return h.marshalJSON()
+}
+
+// DecodeJSON performs default JSON decoding of the [Header].
+func (*NOOPHeaderHooks) DecodeJSON(h *Header, b []byte) error {
+	return h.unmarshalJSON(b)
+}
+
+// EncodeRLP performs default RLP encoding of the [Header].
+func (*NOOPHeaderHooks) EncodeRLP(h *Header, w io.Writer) error {
+	return h.encodeRLP(w)
+}
+
+// DecodeRLP performs default RLP decoding of the [Header].
+func (*NOOPHeaderHooks) DecodeRLP(h *Header, s *rlp.Stream) error {
+	type withoutMethods Header
+	return s.Decode((*withoutMethods)(h))
+}
+
+// PostCopy is a no-op, leaving the payload shared between the source and the
+// copy.
+func (*NOOPHeaderHooks) PostCopy(dst *Header) {}
+
+// BlockBodyHooks are required for all types registered with [RegisterExtras]
+// for [Body] and [Block] payloads.
+//
+// As with [HeaderHooks], the RLP hooks replace the respective encoding and
+// decoding of the [Body] in its entirety. The RLP encoding of a [Block] is
+// always that of its [Header] followed by the (list-flattened) fields of its
+// [Body], as is the case with default geth encodings, so the hooks implicitly
+// define the [Block] encoding too.
+type BlockBodyHooks interface {
+	EncodeRLP(*Body, io.Writer) error
+	DecodeRLP(*Body, *rlp.Stream) error
+	// AddRPCFields is called when marshalling a [Block] for RPC responses
+	// (e.g. eth_getBlockByHash), with the fields that will be returned. It MAY
+	// add to, modify, or remove the fields.
+	AddRPCFields(_ *Block, fields map[string]any)
+	// VerifyAgainstHeader returns an error if the [Body]'s payload isn't
+	// committed to by the [Header], typically via the Header's own payload. It
+	// is called, via [VerifyBodyExtra], wherever bodies received from untrusted
+	// sources are matched to headers, alongside the equivalent checks of
+	// transaction and uncle hashes.
+	VerifyAgainstHeader(*Body, *Header) error
+}
+
+// Hooks returns the [BlockBodyHooks] registered with [RegisterExtras], or
+// [NOOPBlockBodyHooks] if none were registered.
+func (b *Block) Hooks() BlockBodyHooks {
+	if r := registeredExtras; r != nil {
+		return r.hooks.hooksFromBlock(b)
+	}
+	return new(NOOPBlockBodyHooks)
+}
+
+// hooks is the [Body] equivalent of [Block.Hooks].
+func (b *Body) hooks() BlockBodyHooks {
+	if r := registeredExtras; r != nil {
+		return r.hooks.hooksFromBody(b)
+	}
+	return new(NOOPBlockBodyHooks)
+}
+
+var _ interface {
+	rlp.Encoder
+	rlp.Decoder
+} = (*Body)(nil)
+
+// EncodeRLP implements the [rlp.Encoder] interface.
+func (b *Body) EncodeRLP(w io.Writer) error {
+	return b.hooks().EncodeRLP(b, w)
+}
+
+// DecodeRLP implements the [rlp.Decoder] interface.
+func (b *Body) DecodeRLP(s *rlp.Stream) error {
+	return b.hooks().DecodeRLP(b, s)
+}
+
+// encodeRLPWithBodyHooks is equivalent to the default [Block.EncodeRLP] except
+// that it defers encoding of all non-header fields to the [BlockBodyHooks].
+func (b *Block) encodeRLPWithBodyHooks(w io.Writer) error {
+	hdr, err := rlp.EncodeToBytes(b.header)
+	if err != nil {
+		return err
+	}
+	body, err := rlp.EncodeToBytes(b.Body())
+	if err != nil {
+		return err
+	}
+	bodyFields, _, err := rlp.SplitList(body)
+	if err != nil {
+		return err
+	}
+
+	enc := rlp.NewEncoderBuffer(w)
+	l := enc.List()
+	enc.Write(hdr)
+	enc.Write(bodyFields)
+	enc.ListEnd(l)
+	return enc.Flush()
+}
+
+// decodeRLPWithBodyHooks is the inverse of encodeRLPWithBodyHooks().
+func (b *Block) decodeRLPWithBodyHooks(s *rlp.Stream) error {
+	_, size, _ := s.Kind()
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	hdr := new(Header)
+	if err := s.Decode(hdr); err != nil {
+		return err
+	}
+
+	// The remaining fields are those of the [Body], which we re-wrap in an RLP
+	// list so they can be decoded as such.
+	enc := rlp.NewEncoderBuffer(nil)
+	l := enc.List()
+	for {
+		raw, err := s.Raw()
+		if err == rlp.EOL {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		enc.Write(raw)
+	}
+	enc.ListEnd(l)
+	if err := s.ListEnd(); err != nil {
+		return err
+	}
+
+	body := new(Body)
+	if err := rlp.DecodeBytes(enc.ToBytes(), body); err != nil {
+		return err
+	}
+	b.header = hdr
+	b.transactions, b.uncles, b.withdrawals, b.extra = body.Transactions, body.Uncles, body.Withdrawals, body.extra
+	b.size.Store(rlp.ListSize(size))
+	return nil
+}
+
+// WithBodyExtra returns a copy of the block with the [Body]'s extra payload,
+// registered with [RegisterExtras]. Only the payload is copied; all other body
+// fields are carried over from b, as with [Block.WithWithdrawals]. A nil Body is
+// treated as carrying the zero-value payload.
+func (b *Block) WithBodyExtra(body *Body) *Block {
+	block := &Block{
+		header:       b.header,
+		transactions: b.transactions,
+		uncles:       b.uncles,
+		withdrawals:  b.withdrawals,
+	}
+	if body != nil {
+		block.extra = body.extra
+	}
+	return block
+}
+
+// VerifyBodyExtra returns the error, if any, returned by the
+// [BlockBodyHooks.VerifyAgainstHeader] method of the [Body]'s payload. A nil
+// Body is treated as carrying the zero-value payload.
+func VerifyBodyExtra(h *Header, b *Body) error {
+	if b == nil {
+		b = new(Body)
+	}
+	return b.hooks().VerifyAgainstHeader(b, h)
+}
+
+// NewBlockWithBody is equivalent to [NewBlockWithWithdrawals], with the
+// transactions, uncles, and withdrawals taken from the [Body], except that
+// the returned Block also carries the Body's extra payload.
+func NewBlockWithBody(header *Header, body *Body, receipts []*Receipt, hasher TrieHasher) *Block {
+	b := NewBlockWithWithdrawals(header, body.Transactions, body.Uncles, receipts, body.Withdrawals, hasher)
+	b.extra = body.extra
+	return b
+}
+
+// EncodeBodyExtra returns an encoding of the Block's [Body] payload, for use by
+// formats that carry blocks without encoding a Body, such as Engine API
+// payloads. The encoding is that of a Body with no transactions, uncles, or
+// withdrawals, as determined by the [BlockBodyHooks]. It returns nil if no
+// extras are registered.
+func (b *Block) EncodeBodyExtra() ([]byte, error) {
+	if registeredExtras == nil {
+		return nil, nil
+	}
+	return rlp.EncodeToBytes(&Body{extra: b.extra})
+}
+
+// WithEncodedBodyExtra is the inverse of [Block.EncodeBodyExtra], returning a
+// copy of the block as per [Block.WithBodyExtra]. An empty encoding is
+// treated as carrying the zero-value payload.
+func (b *Block) WithEncodedBodyExtra(buf []byte) (*Block, error) {
+	if len(buf) == 0 || registeredExtras == nil {
+		return b.WithBodyExtra(nil), nil
+	}
+	body := new(Body)
+	if err := rlp.DecodeBytes(buf, body); err != nil {
+		return nil, err
+	}
+	return b.WithBodyExtra(body), nil
+}
+
+// NOOPBlockBodyHooks implements [BlockBodyHooks] such that they are equivalent
+// to no type having been registered.
+type NOOPBlockBodyHooks struct{}
+
+var _ BlockBodyHooks = (*NOOPBlockBodyHooks)(nil)
+
+// EncodeRLP performs default RLP encoding of the [Body].
+func (*NOOPBlockBodyHooks) EncodeRLP(b *Body, w io.Writer) error {
+	type withoutMethods Body
+	return rlp.Encode(w, (*withoutMethods)(b))
+}
+
+// DecodeRLP performs default RLP decoding of the [Body].
+func (*NOOPBlockBodyHooks) DecodeRLP(b *Body, s *rlp.Stream) error {
+	type withoutMethods Body
+	return s.Decode((*withoutMethods)(b))
+}
+
+// AddRPCFields leaves the fields unchanged.
+func (*NOOPBlockBodyHooks) AddRPCFields(*Block, map[string]any) {}
+
+// VerifyAgainstHeader always returns nil.
+func (*NOOPBlockBodyHooks) VerifyAgainstHeader(*Body, *Header) error { return nil }
@@ -0,0 +1,414 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm/ethtest"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// gasCostHeader is a [types.HeaderHooks] payload that adds a single field to a
+// [types.Header], wrapping the default geth encodings.
+type gasCostHeader struct {
+	types.NOOPHeaderHooks
+	BlockGasCost uint64
+}
+
+var _ types.HeaderHooks = (*gasCostHeader)(nil)
+
+func gasCostPayloads() types.ExtraPayloads[*gasCostHeader, *types.NOOPBlockBodyHooks, struct{}] {
+	return types.ExtraPayloads[*gasCostHeader, *types.NOOPBlockBodyHooks, struct{}]{}
+}
+
+func (g *gasCostHeader) EncodeRLP(h *types.Header, w io.Writer) error {
+	geth, err := rlp.EncodeToBytes(&withNOOPHooks{h})
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, []any{rlp.RawValue(geth), g.BlockGasCost})
+}
+
+func (g *gasCostHeader) DecodeRLP(h *types.Header, s *rlp.Stream) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	if err := g.NOOPHeaderHooks.DecodeRLP(h, s); err != nil {
+		return err
+	}
+	if err := s.Decode(&g.BlockGasCost); err != nil {
+		return err
+	}
+	return s.ListEnd()
+}
+
+func (g *gasCostHeader) EncodeJSON(h *types.Header) ([]byte, error) {
+	geth, err := g.NOOPHeaderHooks.EncodeJSON(h)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(geth, &fields); err != nil {
+		return nil, err
+	}
+	fields["blockGasCost"], err = json.Marshal(g.BlockGasCost)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+func (g *gasCostHeader) DecodeJSON(h *types.Header, b []byte) error {
+	if err := g.NOOPHeaderHooks.DecodeJSON(h, b); err != nil {
+		return err
+	}
+	var extra struct {
+		BlockGasCost uint64 `json:"blockGasCost"`
+	}
+	if err := json.Unmarshal(b, &extra); err != nil {
+		return err
+	}
+	g.BlockGasCost = extra.BlockGasCost
+	return nil
+}
+
+func (g *gasCostHeader) PostCopy(dst *types.Header) {
+	cp := *g
+	gasCostPayloads().SetOnHeader(dst, &cp)
+}
+
+// withNOOPHooks wraps a [types.Header] to use default geth RLP encoding,
+// regardless of the registered payload.
+type withNOOPHooks struct {
+	*types.Header
+}
+
+func (h *withNOOPHooks) EncodeRLP(w io.Writer) error {
+	return new(types.NOOPHeaderHooks).EncodeRLP(h.Header, w)
+}
+
+func TestHeaderHooks(t *testing.T) {
+	rng := ethtest.NewPseudoRand(13579)
+	newHeader := func() *types.Header {
+		return &types.Header{
+			ParentHash: rng.Hash(),
+			Root:       rng.Hash(),
+			Difficulty: rng.BigUint64(),
+			Number:     rng.BigUint64(),
+			GasLimit:   rng.Uint64(),
+			Time:       rng.Uint64(),
+			Extra:      rng.Bytes(8),
+			BaseFee:    rng.BigUint64(),
+		}
+	}
+
+	vanilla := newHeader()
+	vanillaRLP, err := rlp.EncodeToBytes(vanilla)
+	require.NoErrorf(t, err, "rlp.EncodeToBytes(%T) before registration", vanilla)
+	vanillaHash := vanilla.Hash()
+
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	payloads := types.RegisterExtras[gasCostHeader, *gasCostHeader, types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks, struct{}]()
+
+	const gasCost = 42
+	hdr := types.CopyHeader(vanilla)
+	payloads.FromHeader(hdr).BlockGasCost = gasCost
+
+	t.Run("RLP", func(t *testing.T) {
+		buf, err := rlp.EncodeToBytes(hdr)
+		require.NoErrorf(t, err, "rlp.EncodeToBytes(%T)", hdr)
+		want, err := rlp.EncodeToBytes([]any{rlp.RawValue(vanillaRLP), uint64(gasCost)})
+		require.NoError(t, err, "rlp.EncodeToBytes(<expected encoding>)")
+		assert.Equal(t, want, buf, "RLP encoding wraps geth encoding with payload")
+
+		got := new(types.Header)
+		require.NoErrorf(t, rlp.DecodeBytes(buf, got), "rlp.DecodeBytes(..., %T)", got)
+		assert.Equal(t, hdr.Hash(), got.Hash(), "Hash() after RLP round trip")
+		assert.Equal(t, uint64(gasCost), payloads.FromHeader(got).BlockGasCost, "payload after RLP round trip")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		buf, err := json.Marshal(hdr)
+		require.NoErrorf(t, err, "json.Marshal(%T)", hdr)
+
+		got := new(types.Header)
+		require.NoErrorf(t, json.Unmarshal(buf, got), "json.Unmarshal(..., %T)", got)
+		assert.Equal(t, hdr.Hash(), got.Hash(), "Hash() after JSON round trip")
+		assert.Equal(t, uint64(gasCost), payloads.FromHeader(got).BlockGasCost, "payload after JSON round trip")
+	})
+
+	t.Run("Hash", func(t *testing.T) {
+		assert.NotEqual(t, vanillaHash, hdr.Hash(), "Hash() with payload")
+
+		zero := types.CopyHeader(vanilla)
+		payloads.FromHeader(zero).BlockGasCost = 0
+		assert.NotEqual(t, hdr.Hash(), zero.Hash(), "Hash() with different payload value")
+	})
+
+	t.Run("CopyHeader", func(t *testing.T) {
+		cp := types.CopyHeader(hdr)
+		require.Equal(t, uint64(gasCost), payloads.FromHeader(cp).BlockGasCost, "payload of copy")
+		payloads.FromHeader(cp).BlockGasCost++
+		assert.Equal(t, uint64(gasCost), payloads.FromHeader(hdr).BlockGasCost, "payload of original after modifying copy")
+	})
+
+	t.Run("Block.WithSeal", func(t *testing.T) {
+		block := types.NewBlockWithHeader(newHeader()).WithSeal(hdr)
+		assert.Equal(t, hdr.Hash(), block.Hash(), "Hash() of sealed block")
+		assert.Equal(t, uint64(gasCost), payloads.FromHeader(block.Header()).BlockGasCost, "payload of sealed block's header")
+	})
+
+	t.Run("rawdb", func(t *testing.T) {
+		db := rawdb.NewMemoryDatabase()
+		rawdb.WriteHeader(db, hdr)
+
+		got := rawdb.ReadHeader(db, hdr.Hash(), hdr.Number.Uint64())
+		require.NotNil(t, got, "rawdb.ReadHeader()")
+		assert.Equal(t, hdr.Hash(), got.Hash(), "Hash() of header read from database")
+		assert.Equal(t, uint64(gasCost), payloads.FromHeader(got).BlockGasCost, "payload of header read from database")
+	})
+}
+
+func TestHeaderHooksDefaultEquivalence(t *testing.T) {
+	rng := ethtest.NewPseudoRand(24680)
+	hdr := &types.Header{
+		ParentHash: rng.Hash(),
+		Difficulty: big.NewInt(0),
+		Number:     rng.BigUint64(),
+		Extra:      rng.Bytes(4),
+	}
+
+	wantRLP, err := rlp.EncodeToBytes(hdr)
+	require.NoError(t, err, "rlp.EncodeToBytes() before registration")
+	wantJSON, err := json.Marshal(hdr)
+	require.NoError(t, err, "json.Marshal() before registration")
+
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	types.RegisterExtras[types.NOOPHeaderHooks, *types.NOOPHeaderHooks, types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks, struct{}]()
+
+	gotRLP, err := rlp.EncodeToBytes(hdr)
+	require.NoError(t, err, "rlp.EncodeToBytes() after registration")
+	assert.Equal(t, wantRLP, gotRLP, "RLP encoding with NOOPHeaderHooks registered")
+
+	gotJSON, err := json.Marshal(hdr)
+	require.NoError(t, err, "json.Marshal() after registration")
+	assert.JSONEq(t, string(wantJSON), string(gotJSON), "JSON encoding with NOOPHeaderHooks registered")
+}
+
+// extDataBody is a [types.BlockBodyHooks] payload that replaces withdrawals
+// with an arbitrary byte slice in the RLP encoding of a [types.Body].
+type extDataBody struct {
+	ExtData []byte
+}
+
+var _ types.BlockBodyHooks = (*extDataBody)(nil)
+
+type extDataBodyRLP struct {
+	Txs     []*types.Transaction
+	Uncles  []*types.Header
+	ExtData []byte
+}
+
+func (e *extDataBody) EncodeRLP(b *types.Body, w io.Writer) error {
+	return rlp.Encode(w, &extDataBodyRLP{b.Transactions, b.Uncles, e.ExtData})
+}
+
+func (e *extDataBody) DecodeRLP(b *types.Body, s *rlp.Stream) error {
+	var body extDataBodyRLP
+	if err := s.Decode(&body); err != nil {
+		return err
+	}
+	b.Transactions, b.Uncles, e.ExtData = body.Txs, body.Uncles, body.ExtData
+	return nil
+}
+
+func (e *extDataBody) AddRPCFields(_ *types.Block, fields map[string]any) {
+	fields["extData"] = hexutil.Bytes(e.ExtData)
+}
+
+// VerifyAgainstHeader requires that the header's Extra field is the hash of
+// the ExtData.
+func (e *extDataBody) VerifyAgainstHeader(_ *types.Body, h *types.Header) error {
+	if !bytes.Equal(crypto.Keccak256(e.ExtData), h.Extra) {
+		return errors.New("ExtData not committed to by header")
+	}
+	return nil
+}
+
+func TestBlockBodyHooks(t *testing.T) {
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	payloads := types.RegisterExtras[types.NOOPHeaderHooks, *types.NOOPHeaderHooks, extDataBody, *extDataBody, struct{}]()
+
+	rng := ethtest.NewPseudoRand(97531)
+	hdr := &types.Header{
+		ParentHash: rng.Hash(),
+		Difficulty: rng.BigUint64(),
+		Number:     rng.BigUint64(),
+	}
+	uncle := &types.Header{
+		ParentHash: rng.Hash(),
+		Difficulty: rng.BigUint64(),
+		Number:     rng.BigUint64(),
+	}
+	extData := rng.Bytes(16)
+	hdr.Extra = crypto.Keccak256(extData)
+
+	block := types.NewBlockWithHeader(hdr).WithBody(nil, []*types.Header{uncle})
+	payloads.SetOnBlock(block, &extDataBody{ExtData: extData})
+
+	assertBlock := func(t *testing.T, got *types.Block) {
+		t.Helper()
+		assert.Equal(t, block.Hash(), got.Hash(), "Hash()")
+		assert.Equal(t, types.CalcUncleHash(block.Uncles()), types.CalcUncleHash(got.Uncles()), "CalcUncleHash(Uncles())")
+		assert.Equal(t, extData, payloads.FromBlock(got).ExtData, "payload")
+	}
+
+	t.Run("Body", func(t *testing.T) {
+		body := block.Body()
+		assert.Equal(t, extData, payloads.FromBody(body).ExtData, "payload of Block.Body()")
+
+		buf, err := rlp.EncodeToBytes(body)
+		require.NoErrorf(t, err, "rlp.EncodeToBytes(%T)", body)
+		want, err := rlp.EncodeToBytes(&extDataBodyRLP{nil, []*types.Header{uncle}, extData})
+		require.NoError(t, err, "rlp.EncodeToBytes(<expected encoding>)")
+		assert.Equal(t, want, buf, "RLP encoding determined by hooks")
+
+		got := new(types.Body)
+		require.NoErrorf(t, rlp.DecodeBytes(buf, got), "rlp.DecodeBytes(..., %T)", got)
+		assert.Equal(t, extData, payloads.FromBody(got).ExtData, "payload after RLP round trip")
+	})
+
+	t.Run("Block_RLP", func(t *testing.T) {
+		buf, err := rlp.EncodeToBytes(block)
+		require.NoErrorf(t, err, "rlp.EncodeToBytes(%T)", block)
+		want, err := rlp.EncodeToBytes([]any{hdr, []*types.Transaction{}, []*types.Header{uncle}, extData})
+		require.NoError(t, err, "rlp.EncodeToBytes(<expected encoding>)")
+		assert.Equal(t, want, buf, "RLP encoding is header followed by body fields")
+
+		got := new(types.Block)
+		require.NoErrorf(t, rlp.DecodeBytes(buf, got), "rlp.DecodeBytes(..., %T)", got)
+		assertBlock(t, got)
+		assert.Equal(t, uint64(len(buf)), got.Size(), "Size() after decoding")
+	})
+
+	t.Run("Block_copies", func(t *testing.T) {
+		for name, b := range map[string]*types.Block{
+			"WithSeal":        block.WithSeal(hdr),
+			"WithBody":        block.WithBody(nil, []*types.Header{uncle}),
+			"WithWithdrawals": block.WithWithdrawals(nil),
+			"WithBodyExtra":   types.NewBlockWithHeader(hdr).WithBody(nil, []*types.Header{uncle}).WithBodyExtra(block.Body()),
+		} {
+			t.Run(name, func(t *testing.T) {
+				assertBlock(t, b)
+			})
+		}
+	})
+
+	t.Run("rawdb", func(t *testing.T) {
+		db := rawdb.NewMemoryDatabase()
+		rawdb.WriteBlock(db, block)
+
+		body := rawdb.ReadBody(db, block.Hash(), block.NumberU64())
+		require.NotNil(t, body, "rawdb.ReadBody()")
+		assert.Equal(t, extData, payloads.FromBody(body).ExtData, "payload of body read from database")
+
+		got := rawdb.ReadBlock(db, block.Hash(), block.NumberU64())
+		require.NotNil(t, got, "rawdb.ReadBlock()")
+		assertBlock(t, got)
+	})
+
+	t.Run("AddRPCFields", func(t *testing.T) {
+		fields := make(map[string]any)
+		block.Hooks().AddRPCFields(block, fields)
+		assert.Equal(t, map[string]any{"extData": hexutil.Bytes(extData)}, fields)
+	})
+
+	t.Run("VerifyBodyExtra", func(t *testing.T) {
+		assert.NoError(t, types.VerifyBodyExtra(hdr, block.Body()), "with committed payload")
+
+		other := &types.Body{}
+		payloads.SetOnBody(other, &extDataBody{ExtData: rng.Bytes(16)})
+		assert.Error(t, types.VerifyBodyExtra(hdr, other), "with uncommitted payload")
+	})
+
+	t.Run("NewBlockWithBody", func(t *testing.T) {
+		got := types.NewBlockWithBody(hdr, block.Body(), nil, nil)
+		assert.Equal(t, extData, payloads.FromBlock(got).ExtData, "payload")
+	})
+
+	t.Run("EncodeBodyExtra", func(t *testing.T) {
+		buf, err := block.EncodeBodyExtra()
+		require.NoErrorf(t, err, "%T.EncodeBodyExtra()", block)
+
+		got, err := types.NewBlockWithHeader(hdr).WithBody(nil, []*types.Header{uncle}).WithEncodedBodyExtra(buf)
+		require.NoErrorf(t, err, "%T.WithEncodedBodyExtra()", block)
+		assertBlock(t, got)
+	})
+}
+
+func TestBlockBodyHooksDefaultEquivalence(t *testing.T) {
+	rng := ethtest.NewPseudoRand(86420)
+	hdr := &types.Header{
+		ParentHash: rng.Hash(),
+		Difficulty: rng.BigUint64(),
+		Number:     rng.BigUint64(),
+	}
+	block := types.NewBlockWithHeader(hdr).WithWithdrawals([]*types.Withdrawal{{
+		Index:   rng.Uint64(),
+		Address: rng.Address(),
+		Amount:  rng.Uint64(),
+	}})
+
+	encode := func(t *testing.T) (blockRLP, bodyRLP []byte) {
+		t.Helper()
+		blockRLP, err := rlp.EncodeToBytes(block)
+		require.NoError(t, err, "rlp.EncodeToBytes(*types.Block)")
+		bodyRLP, err = rlp.EncodeToBytes(block.Body())
+		require.NoError(t, err, "rlp.EncodeToBytes(*types.Body)")
+		return blockRLP, bodyRLP
+	}
+	wantBlock, wantBody := encode(t)
+
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	types.RegisterExtras[types.NOOPHeaderHooks, *types.NOOPHeaderHooks, types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks, struct{}]()
+
+	gotBlock, gotBody := encode(t)
+	assert.Equal(t, wantBlock, gotBlock, "Block RLP with NOOPBlockBodyHooks registered")
+	assert.Equal(t, wantBody, gotBody, "Body RLP with NOOPBlockBodyHooks registered")
+
+	got := new(types.Block)
+	require.NoError(t, rlp.DecodeBytes(gotBlock, got), "rlp.DecodeBytes(..., *types.Block)")
+	assert.Equal(t, block.Hash(), got.Hash(), "Hash() after RLP round trip")
+	assert.Equal(t, block.Withdrawals(), got.Withdrawals(), "Withdrawals() after RLP round trip")
+}
@@ -21,6 +21,7 @@ func (a Account) MarshalJSON() ([]byte, error) {
 		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
 		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
 		Nonce      math.HexOrDecimal64         `json:"nonce,omitempty"`
+		Extra      *StateAccountExtra          `json:"extra,omitempty"`
 		PrivateKey hexutil.Bytes               `json:"secretKey,omitempty"`
 	}
 	var enc Account
@@ -33,6 +34,7 @@ func (a Account) MarshalJSON() ([]byte, error) {
 	}
 	enc.Balance = (*math.HexOrDecimal256)(a.Balance)
 	enc.Nonce = math.HexOrDecimal64(a.Nonce)
+	enc.Extra = a.Extra
 	enc.PrivateKey = a.PrivateKey
 	return json.Marshal(&enc)
 }
@@ -44,6 +46,7 @@ func (a *Account) UnmarshalJSON(input []byte) error {
 		Storage    map[storageJSON]storageJSON `json:"storage,omitempty"`
 		Balance    *math.HexOrDecimal256       `json:"balance" gencodec:"required"`
 		Nonce      *math.HexOrDecimal64        `json:"nonce,omitempty"`
+		Extra      *StateAccountExtra          `json:"extra,omitempty"`
 		PrivateKey *hexutil.Bytes              `json:"secretKey,omitempty"`
 	}
 	var dec Account
@@ -66,6 +69,9 @@ func (a *Account) UnmarshalJSON(input []byte) error {
 	if dec.Nonce != nil {
 		a.Nonce = uint64(*dec.Nonce)
 	}
+	if dec.Extra != nil {
+		a.Extra = dec.Extra
+	}
 	if dec.PrivateKey != nil {
 		a.PrivateKey = *dec.PrivateKey
 	}
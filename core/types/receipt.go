@@ -26,12 +26,13 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
 //go:generate go run github.com/fjl/gencodec -type Receipt -field-override receiptMarshaling -out gen_receipt_json.go
+//go:generate go run ../../libevm/cmd/internalise -file gen_receipt_json.go Receipt.MarshalJSON Receipt.UnmarshalJSON
 
 var (
 	receiptStatusFailedRLP     = []byte{}
@@ -71,6 +72,8 @@ type Receipt struct {
 	BlockHash        common.Hash `json:"blockHash,omitempty"`
 	BlockNumber      *big.Int    `json:"blockNumber,omitempty"`
 	TransactionIndex uint        `json:"transactionIndex"`
+
+	extra *pseudo.Type // See RegisterReceiptExtras()
 }
 
 type receiptMarshaling struct {
@@ -120,7 +123,7 @@ func NewReceipt(root []byte, failed bool, cumulativeGasUsed uint64) *Receipt {
 // EncodeRLP implements rlp.Encoder, and flattens the consensus fields of a receipt
 // into an RLP stream. If no post state is present, byzantium fork is assumed.
 func (r *Receipt) EncodeRLP(w io.Writer) error {
-	data := &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs}
+	data := (*consensusReceipt)(r)
 	if r.Type == LegacyTxType {
 		return rlp.Encode(w, data)
 	}
@@ -134,7 +137,7 @@ func (r *Receipt) EncodeRLP(w io.Writer) error {
 }
 
 // encodeTyped writes the canonical encoding of a typed receipt to w.
-func (r *Receipt) encodeTyped(data *receiptRLP, w *bytes.Buffer) error {
+func (r *Receipt) encodeTyped(data *consensusReceipt, w *bytes.Buffer) error {
 	w.WriteByte(r.Type)
 	return rlp.Encode(w, data)
 }
@@ -144,7 +147,7 @@ func (r *Receipt) MarshalBinary() ([]byte, error) {
 	if r.Type == LegacyTxType {
 		return rlp.EncodeToBytes(r)
 	}
-	data := &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs}
+	data := (*consensusReceipt)(r)
 	var buf bytes.Buffer
 	err := r.encodeTyped(data, &buf)
 	return buf.Bytes(), err
@@ -159,12 +162,11 @@ func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
 		return err
 	case kind == rlp.List:
 		// It's a legacy receipt.
-		var dec receiptRLP
-		if err := s.Decode(&dec); err != nil {
+		if err := s.Decode((*consensusReceipt)(r)); err != nil {
 			return err
 		}
 		r.Type = LegacyTxType
-		return r.setFromRLP(dec)
+		return nil
 	case kind == rlp.Byte:
 		return errShortTypedReceipt
 	default:
@@ -186,13 +188,12 @@ func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
 func (r *Receipt) UnmarshalBinary(b []byte) error {
 	if len(b) > 0 && b[0] > 0x7f {
 		// It's a legacy receipt decode the RLP
-		var data receiptRLP
-		err := rlp.DecodeBytes(b, &data)
+		err := rlp.DecodeBytes(b, (*consensusReceipt)(r))
 		if err != nil {
 			return err
 		}
 		r.Type = LegacyTxType
-		return r.setFromRLP(data)
+		return nil
 	}
 	// It's an EIP2718 typed transaction envelope.
 	return r.decodeTyped(b)
@@ -203,15 +204,19 @@ func (r *Receipt) decodeTyped(b []byte) error {
 	if len(b) <= 1 {
 		return errShortTypedReceipt
 	}
-	switch b[0] {
+	typ := b[0]
+	if isCustomTxType(typ) { // libevm
+		// Receipt encoding is independent of the transaction type.
+		typ = DynamicFeeTxType
+	}
+	switch typ {
 	case DynamicFeeTxType, AccessListTxType, BlobTxType:
-		var data receiptRLP
-		err := rlp.DecodeBytes(b[1:], &data)
+		err := rlp.DecodeBytes(b[1:], (*consensusReceipt)(r))
 		if err != nil {
 			return err
 		}
 		r.Type = b[0]
-		return r.setFromRLP(data)
+		return nil
 	default:
 		return ErrTxTypeNotSupported
 	}
@@ -261,9 +266,8 @@ func (r *Receipt) Size() common.StorageSize {
 // that omits the Bloom field and deserialization that re-computes it.
 type ReceiptForStorage Receipt
 
-// EncodeRLP implements rlp.Encoder, and flattens all content fields of a receipt
-// into an RLP stream.
-func (r *ReceiptForStorage) EncodeRLP(_w io.Writer) error {
+// encodeRLP flattens all content fields of a receipt into an RLP stream.
+func (r *ReceiptForStorage) encodeRLP(_w io.Writer) error {
 	w := rlp.NewEncoderBuffer(_w)
 	outerList := w.List()
 	w.WriteBytes((*Receipt)(r).statusEncoding())
@@ -279,9 +283,9 @@ func (r *ReceiptForStorage) EncodeRLP(_w io.Writer) error {
 	return w.Flush()
 }
 
-// DecodeRLP implements rlp.Decoder, and loads both consensus and implementation
-// fields of a receipt from an RLP stream.
-func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
+// decodeRLP loads both consensus and implementation fields of a receipt from an
+// RLP stream.
+func (r *ReceiptForStorage) decodeRLP(s *rlp.Stream) error {
 	var stored storedReceiptRLP
 	if err := s.Decode(&stored); err != nil {
 		return err
@@ -305,7 +309,7 @@ func (rs Receipts) Len() int { return len(rs) }
 // EncodeIndex encodes the i'th receipt to w.
 func (rs Receipts) EncodeIndex(i int, w *bytes.Buffer) {
 	r := rs[i]
-	data := &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs}
+	data := (*consensusReceipt)(r)
 	if r.Type == LegacyTxType {
 		rlp.Encode(w, data)
 		return
@@ -315,6 +319,10 @@ func (rs Receipts) EncodeIndex(i int, w *bytes.Buffer) {
 	case AccessListTxType, DynamicFeeTxType, BlobTxType:
 		rlp.Encode(w, data)
 	default:
+		if isCustomTxType(r.Type) { // libevm
+			rlp.Encode(w, data)
+			return
+		}
 		// For unsupported types, write nothing. Since this is for
 		// DeriveSha, the error will be caught matching the derived hash
 		// to the block.
@@ -351,7 +359,7 @@ func (rs Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, nu
 		if txs[i].To() == nil {
 			// Deriving the signer is expensive, only do if it's actually needed
 			from, _ := Sender(signer, txs[i])
-			rs[i].ContractAddress = crypto.CreateAddress(from, txs[i].Nonce())
+			rs[i].ContractAddress = contractAddress(config, number, time, from, txs[i].Nonce()) // libevm: overridable
 		} else {
 			rs[i].ContractAddress = common.Address{}
 		}
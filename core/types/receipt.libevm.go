@@ -0,0 +1,258 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
+	"github.com/ethereum/go-ethereum/libevm/testonly"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReceiptHooks are required for all types registered with
+// [RegisterReceiptExtras].
+//
+// As with [HeaderHooks], the hooks replace, in their entirety, the respective
+// encoding and decoding of the [Receipt] that they are attached to. There are
+// two distinct RLP encodings of a receipt:
+//
+//   - The consensus encoding is used for the receipts root of a [Header], via
+//     [DeriveSha], and in the eth wire protocol. For typed receipts, it is the
+//     encoding that follows the type byte. Any payload included in it is
+//     therefore subject to consensus.
+//   - The storage encoding, used by [ReceiptForStorage], is written to the
+//     database by rawdb.WriteReceipts() and read back, before calls to
+//     [Receipts.DeriveFields], by rawdb.ReadReceipts().
+//
+// Implementations that only wish to modify a subset of behaviour SHOULD embed
+// [NOOPReceiptHooks], which reproduces default geth behaviour. In particular,
+// a payload that is only required locally (i.e. not subject to consensus)
+// SHOULD only modify the storage encoding.
+type ReceiptHooks interface {
+	EncodeConsensusRLP(*Receipt, io.Writer) error
+	DecodeConsensusRLP(*Receipt, *rlp.Stream) error
+	EncodeStorageRLP(*Receipt, io.Writer) error
+	DecodeStorageRLP(*Receipt, *rlp.Stream) error
+	EncodeJSON(*Receipt) ([]byte, error)
+	DecodeJSON(*Receipt, []byte) error
+	// AddRPCFields is called when marshalling a [Receipt] for RPC responses
+	// (e.g. eth_getTransactionReceipt), with the fields that will be returned.
+	// It MAY add to, modify, or remove the fields.
+	AddRPCFields(_ *Receipt, fields map[string]any)
+}
+
+// RegisterReceiptExtras registers the type `RPtr` to be carried as an extra
+// payload in [Receipt] structs. It is expected to be called in an `init()`
+// function and MUST NOT be called more than once.
+//
+// The payload is a non-nil pointer to a new `R`, which acts as the
+// [ReceiptHooks] for all [Receipt] and [ReceiptForStorage] encoding and
+// decoding. Receipts derived from the storage encoding carry the payload
+// through [Receipts.DeriveFields], which doesn't modify it.
+//
+// The payload can be accessed via the [ReceiptExtraPayloads.FromReceipt]
+// method of the accessor returned by RegisterReceiptExtras.
+func RegisterReceiptExtras[R any, RPtr interface {
+	ReceiptHooks
+	*R
+}]() ReceiptExtraPayloads[RPtr] {
+	if registeredReceiptExtras != nil {
+		panic("re-registration of receipt extras")
+	}
+	var extra ReceiptExtraPayloads[RPtr]
+	registeredReceiptExtras = &receiptExtraConstructors{
+		newReceipt: pseudo.NewConstructor[R]().NewPointer, // i.e. non-nil RPtr
+		hooks:      extra,
+	}
+	return extra
+}
+
+// TestOnlyClearRegisteredReceiptExtras clears the type previously passed to
+// [RegisterReceiptExtras]. It panics if called from a non-testing call stack.
+//
+// In tests it SHOULD be called before every call to [RegisterReceiptExtras] and
+// then defer-called afterwards, either directly or via testing.TB.Cleanup().
+// This is a workaround for the single-call limitation on
+// [RegisterReceiptExtras].
+func TestOnlyClearRegisteredReceiptExtras() {
+	testonly.OrPanic(func() {
+		registeredReceiptExtras = nil
+	})
+}
+
+var registeredReceiptExtras *receiptExtraConstructors
+
+type receiptExtraConstructors struct {
+	newReceipt func() *pseudo.Type
+	hooks      interface {
+		hooksFromReceipt(*Receipt) ReceiptHooks
+	}
+}
+
+// ReceiptExtraPayloads provides strongly typed access to the extra payloads
+// carried by [Receipt] structs. The only valid way to construct an instance is
+// by a call to [RegisterReceiptExtras].
+type ReceiptExtraPayloads[RPtr ReceiptHooks] struct {
+	_ struct{} // make godoc show unexported fields so nobody tries to make their own instance ;)
+}
+
+// FromReceipt returns the Receipt's payload.
+func (ReceiptExtraPayloads[RPtr]) FromReceipt(r *Receipt) RPtr {
+	return pseudo.MustNewValue[RPtr](r.extraPayload()).Get()
+}
+
+// SetOnReceipt sets the Receipt's payload.
+func (ReceiptExtraPayloads[RPtr]) SetOnReceipt(r *Receipt, val RPtr) {
+	r.extra = pseudo.From(val).Type
+}
+
+// hooksFromReceipt is the [Receipt] equivalent of
+// [ExtraPayloads.hooksFromHeader].
+func (e ReceiptExtraPayloads[RPtr]) hooksFromReceipt(r *Receipt) ReceiptHooks {
+	return e.FromReceipt(r)
+}
+
+// extraPayload is equivalent to [Header.extraPayload].
+func (r *Receipt) extraPayload() *pseudo.Type {
+	if r.extra == nil {
+		r.extra = registeredReceiptExtras.newReceipt()
+	}
+	return r.extra
+}
+
+// Hooks returns the [ReceiptHooks] registered with [RegisterReceiptExtras], or
+// [NOOPReceiptHooks] if none were registered.
+func (r *Receipt) Hooks() ReceiptHooks {
+	if x := registeredReceiptExtras; x != nil {
+		return x.hooks.hooksFromReceipt(r)
+	}
+	return new(NOOPReceiptHooks)
+}
+
+var _ interface {
+	json.Marshaler
+	json.Unmarshaler
+} = (*Receipt)(nil)
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (r Receipt) MarshalJSON() ([]byte, error) {
+	return r.Hooks().EncodeJSON(&r)
+}
+
+// UnmarshalJSON implements the [json.Unmarshaler] interface.
+func (r *Receipt) UnmarshalJSON(b []byte) error {
+	return r.Hooks().DecodeJSON(r, b)
+}
+
+// consensusReceipt is a [Receipt] that uses its [ReceiptHooks] for RLP
+// encoding and decoding of the consensus fields, i.e. excluding the envelope of
+// typed receipts.
+type consensusReceipt Receipt
+
+var _ interface {
+	rlp.Encoder
+	rlp.Decoder
+} = (*consensusReceipt)(nil)
+
+func (c *consensusReceipt) EncodeRLP(w io.Writer) error {
+	r := (*Receipt)(c)
+	return r.Hooks().EncodeConsensusRLP(r, w)
+}
+
+func (c *consensusReceipt) DecodeRLP(s *rlp.Stream) error {
+	r := (*Receipt)(c)
+	return r.Hooks().DecodeConsensusRLP(r, s)
+}
+
+var _ interface {
+	rlp.Encoder
+	rlp.Decoder
+} = (*ReceiptForStorage)(nil)
+
+// EncodeRLP implements the [rlp.Encoder] interface, flattening all content
+// fields of a receipt into an RLP stream.
+func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
+	rr := (*Receipt)(r)
+	return rr.Hooks().EncodeStorageRLP(rr, w)
+}
+
+// DecodeRLP implements the [rlp.Decoder] interface, loading both consensus and
+// implementation fields of a receipt from an RLP stream.
+func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
+	rr := (*Receipt)(r)
+	return rr.Hooks().DecodeStorageRLP(rr, s)
+}
+
+// NOOPReceiptHooks implements [ReceiptHooks] such that they are equivalent to
+// no type having been registered.
+type NOOPReceiptHooks struct{}
+
+var _ ReceiptHooks = (*NOOPReceiptHooks)(nil)
+
+// EncodeConsensusRLP performs default consensus RLP encoding of the [Receipt].
+func (*NOOPReceiptHooks) EncodeConsensusRLP(r *Receipt, w io.Writer) error {
+	return rlp.Encode(w, &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs})
+}
+
+// DecodeConsensusRLP performs default consensus RLP decoding of the [Receipt].
+func (*NOOPReceiptHooks) DecodeConsensusRLP(r *Receipt, s *rlp.Stream) error {
+	var dec receiptRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	return r.setFromRLP(dec)
+}
+
+// EncodeStorageRLP performs default storage RLP encoding of the [Receipt].
+func (*NOOPReceiptHooks) EncodeStorageRLP(r *Receipt, w io.Writer) error {
+	return (*ReceiptForStorage)(r).encodeRLP(w)
+}
+
+// DecodeStorageRLP performs default storage RLP decoding of the [Receipt].
+func (*NOOPReceiptHooks) DecodeStorageRLP(r *Receipt, s *rlp.Stream) error {
+	return (*ReceiptForStorage)(r).decodeRLP(s)
+}
+
+// EncodeJSON performs default JSON encoding of the [Receipt].
+func (*NOOPReceiptHooks) EncodeJSON(r *Receipt) ([]byte, error) {
+	return r.marshalJSON()
+}
+
+// DecodeJSON performs default JSON decoding of the [Receipt].
+func (*NOOPReceiptHooks) DecodeJSON(r *Receipt, b []byte) error {
+	return r.unmarshalJSON(b)
+}
+
+// AddRPCFields leaves the fields unchanged.
+func (*NOOPReceiptHooks) AddRPCFields(*Receipt, map[string]any) {}
+
+// contractAddress returns the address of the contract deployed by a
+// transaction, for use by [Receipts.DeriveFields]. As the block header isn't
+// available, the merge flag passed to [params.ChainConfig.Rules] is the
+// config's TerminalTotalDifficultyPassed, which isn't necessarily that of the
+// block. The params.RulesHooks.ContractAddressOverride hook MUST therefore not
+// depend on Rules.IsMerge, nor on any fork that is gated by it.
+func contractAddress(config *params.ChainConfig, number, time uint64, from common.Address, nonce uint64) common.Address {
+	rules := config.Rules(new(big.Int).SetUint64(number), config.TerminalTotalDifficultyPassed, time)
+	return rules.CreateAddress(from, nonce)
+}
@@ -0,0 +1,252 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm"
+	"github.com/ethereum/go-ethereum/libevm/ethtest"
+	"github.com/ethereum/go-ethereum/libevm/hookstest"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// messageIDReceipt carries a cross-chain message ID that is local to the node,
+// i.e. it is only included in the storage encoding and not the consensus one.
+type messageIDReceipt struct {
+	types.NOOPReceiptHooks
+	MessageID common.Hash
+}
+
+func (m *messageIDReceipt) EncodeStorageRLP(r *types.Receipt, w io.Writer) error {
+	b := rlp.NewEncoderBuffer(w)
+	l := b.List()
+	if err := m.NOOPReceiptHooks.EncodeStorageRLP(r, b); err != nil {
+		return err
+	}
+	b.WriteBytes(m.MessageID[:])
+	b.ListEnd(l)
+	return b.Flush()
+}
+
+func (m *messageIDReceipt) DecodeStorageRLP(r *types.Receipt, s *rlp.Stream) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	if err := m.NOOPReceiptHooks.DecodeStorageRLP(r, s); err != nil {
+		return err
+	}
+	if err := s.Decode(&m.MessageID); err != nil {
+		return err
+	}
+	return s.ListEnd()
+}
+
+func (m *messageIDReceipt) EncodeJSON(r *types.Receipt) ([]byte, error) {
+	buf, err := m.NOOPReceiptHooks.EncodeJSON(r)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(buf, &fields); err != nil {
+		return nil, err
+	}
+	m.AddRPCFields(r, fields)
+	return json.Marshal(fields)
+}
+
+func (m *messageIDReceipt) DecodeJSON(r *types.Receipt, b []byte) error {
+	if err := m.NOOPReceiptHooks.DecodeJSON(r, b); err != nil {
+		return err
+	}
+	var dec struct {
+		MessageID common.Hash `json:"messageId"`
+	}
+	if err := json.Unmarshal(b, &dec); err != nil {
+		return err
+	}
+	m.MessageID = dec.MessageID
+	return nil
+}
+
+func (m *messageIDReceipt) AddRPCFields(_ *types.Receipt, fields map[string]any) {
+	fields["messageId"] = m.MessageID
+}
+
+func TestReceiptHooks(t *testing.T) {
+	rng := ethtest.NewPseudoRand(1337)
+
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    rng.Uint64(),
+		To:       rng.AddressPtr(),
+		Gas:      21_000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	})
+	newReceipt := func() *types.Receipt {
+		return &types.Receipt{
+			Type:              types.LegacyTxType,
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21_000,
+			Logs: []*types.Log{{
+				Address: rng.Address(),
+				Topics:  []common.Hash{rng.Hash()},
+				Data:    rng.Bytes(8),
+			}},
+		}
+	}
+	vanilla := newReceipt()
+	vanilla.Bloom = types.CreateBloom(types.Receipts{vanilla})
+	vanillaRoot := types.DeriveSha(types.Receipts{vanilla}, trie.NewStackTrie(nil))
+
+	types.TestOnlyClearRegisteredReceiptExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredReceiptExtras)
+	extras := types.RegisterReceiptExtras[messageIDReceipt]()
+
+	receipt := &types.Receipt{}
+	*receipt = *vanilla
+	msgID := rng.Hash()
+	extras.SetOnReceipt(receipt, &messageIDReceipt{MessageID: msgID})
+
+	t.Run("consensus_encoding", func(t *testing.T) {
+		got := types.DeriveSha(types.Receipts{receipt}, trie.NewStackTrie(nil))
+		assert.Equal(t, vanillaRoot, got, "receipts root unchanged by storage-only payload")
+
+		buf, err := rlp.EncodeToBytes(receipt)
+		require.NoError(t, err, "rlp.EncodeToBytes(Receipt)")
+		want, err := rlp.EncodeToBytes(vanilla)
+		require.NoError(t, err, "rlp.EncodeToBytes([vanilla geth Receipt])")
+		assert.Equal(t, want, buf, "consensus RLP unchanged by storage-only payload")
+	})
+
+	t.Run("storage_encoding", func(t *testing.T) {
+		buf, err := rlp.EncodeToBytes((*types.ReceiptForStorage)(receipt))
+		require.NoError(t, err, "rlp.EncodeToBytes(ReceiptForStorage)")
+
+		got := new(types.ReceiptForStorage)
+		require.NoError(t, rlp.DecodeBytes(buf, got), "rlp.DecodeBytes(..., ReceiptForStorage)")
+		assert.Equal(t, msgID, extras.FromReceipt((*types.Receipt)(got)).MessageID, "payload after round trip")
+		assert.Equal(t, receipt.Bloom, got.Bloom, "Bloom after round trip")
+	})
+
+	t.Run("rawdb", func(t *testing.T) {
+		db := rawdb.NewMemoryDatabase()
+		hdr := &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(1)}
+		hash := hdr.Hash()
+		rawdb.WriteHeader(db, hdr)
+		rawdb.WriteBody(db, hash, 1, &types.Body{Transactions: types.Transactions{tx}})
+		rawdb.WriteReceipts(db, hash, 1, types.Receipts{receipt})
+
+		got := rawdb.ReadReceipts(db, hash, 1, 0, params.TestChainConfig)
+		require.Len(t, got, 1, "rawdb.ReadReceipts()")
+		assert.Equal(t, msgID, extras.FromReceipt(got[0]).MessageID, "payload read from database")
+		assert.Equal(t, tx.Hash(), got[0].TxHash, "TxHash set by DeriveFields()")
+
+		logs := rawdb.ReadLogs(db, hash, 1)
+		require.Len(t, logs, 1, "rawdb.ReadLogs()")
+		assert.Equal(t, receipt.Logs[0].Data, logs[0][0].Data, "rawdb.ReadLogs()[0][0].Data")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		buf, err := json.Marshal(receipt)
+		require.NoError(t, err, "json.Marshal(Receipt)")
+
+		got := new(types.Receipt)
+		require.NoError(t, json.Unmarshal(buf, got), "json.Unmarshal(..., Receipt)")
+		assert.Equal(t, msgID, extras.FromReceipt(got).MessageID, "payload after JSON round trip")
+		assert.Equal(t, receipt.CumulativeGasUsed, got.CumulativeGasUsed, "CumulativeGasUsed after JSON round trip")
+
+		fields := make(map[string]any)
+		receipt.Hooks().AddRPCFields(receipt, fields)
+		assert.Equal(t, map[string]any{"messageId": msgID}, fields, "AddRPCFields()")
+	})
+}
+
+func TestReceiptHooksDefaultEquivalence(t *testing.T) {
+	types.TestOnlyClearRegisteredReceiptExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredReceiptExtras)
+	types.RegisterReceiptExtras[types.NOOPReceiptHooks]()
+
+	rng := ethtest.NewPseudoRand(42)
+	for _, typ := range []uint8{types.LegacyTxType, types.DynamicFeeTxType} {
+		r := &types.Receipt{
+			Type:              typ,
+			PostState:         rng.Hash().Bytes(),
+			CumulativeGasUsed: rng.Uint64(),
+			Logs:              []*types.Log{},
+		}
+		r.Bloom = types.CreateBloom(types.Receipts{r})
+
+		buf, err := r.MarshalBinary()
+		require.NoError(t, err, "Receipt.MarshalBinary()")
+		got := new(types.Receipt)
+		require.NoError(t, got.UnmarshalBinary(buf), "Receipt.UnmarshalBinary()")
+		assert.Equal(t, r.PostState, got.PostState, "PostState after round trip")
+		assert.Equal(t, r.Type, got.Type, "Type after round trip")
+
+		stored, err := rlp.EncodeToBytes((*types.ReceiptForStorage)(r))
+		require.NoError(t, err, "rlp.EncodeToBytes(ReceiptForStorage)")
+		fromStorage := new(types.ReceiptForStorage)
+		require.NoError(t, rlp.DecodeBytes(stored, fromStorage), "rlp.DecodeBytes(..., ReceiptForStorage)")
+		assert.Equal(t, r.CumulativeGasUsed, fromStorage.CumulativeGasUsed, "CumulativeGasUsed after storage round trip")
+	}
+}
+
+func TestDeriveFieldsContractAddress(t *testing.T) {
+	rng := ethtest.NewPseudoRand(1414)
+	override := rng.Address()
+	var gotArgs *libevm.ContractAddressArgs
+	hooks := &hookstest.Stub{
+		ContractAddressFn: func(args *libevm.ContractAddressArgs) common.Address {
+			gotArgs = args
+			return override
+		},
+	}
+	hooks.Register(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err, "crypto.GenerateKey()")
+	signer := types.LatestSigner(params.TestChainConfig)
+	const nonce = 42
+	tx := types.MustSignNewTx(key, signer, &types.LegacyTx{
+		Nonce:    nonce,
+		Gas:      1e6,
+		GasPrice: big.NewInt(1),
+	})
+
+	receipts := types.Receipts{{Status: types.ReceiptStatusSuccessful}}
+	require.NoError(t, receipts.DeriveFields(params.TestChainConfig, rng.Hash(), 1, 0, big.NewInt(0), big.NewInt(0), types.Transactions{tx}), "DeriveFields()")
+
+	assert.Equal(t, override, receipts[0].ContractAddress, "ContractAddress")
+	want := &libevm.ContractAddressArgs{
+		Caller: crypto.PubkeyToAddress(key.PublicKey),
+		Nonce:  nonce,
+	}
+	assert.Equal(t, want, gotArgs, "arguments to ContractAddressOverride hook")
+}
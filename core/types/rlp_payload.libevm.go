@@ -17,37 +17,78 @@
 package types
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/libevm/pseudo"
 	"github.com/ethereum/go-ethereum/libevm/testonly"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
-// RegisterExtras registers the type `SA` to be carried as an extra payload in
-// [StateAccount] structs. It is expected to be called in an `init()` function
-// and MUST NOT be called more than once.
+// RegisterExtras registers the type `HPtr` to be carried as an extra payload in
+// [Header] structs, the type `BPtr` in [Body] and [Block] structs, and the type
+// `SA` in [StateAccount] structs. It is expected to be called in an `init()`
+// function and MUST NOT be called more than once.
 //
-// The payload will be treated as an extra struct field for the purposes of RLP
-// encoding and decoding. RLP handling is plumbed through to the `SA` via the
-// [StateAccountExtra] that holds it such that it acts as if there were a field
-// of type `SA` in all StateAccount structs.
+// The `SA` payload will be treated as an extra struct field for the purposes of
+// RLP encoding and decoding. RLP handling is plumbed through to the `SA` via
+// the [StateAccountExtra] that holds it such that it acts as if there were a
+// field of type `SA` in all StateAccount structs.
 //
-// The payload can be acced via the [ExtraPayloads.FromStateAccount] method of
-// the accessor returned by RegisterExtras.
-func RegisterExtras[SA any]() ExtraPayloads[SA] {
+// The `HPtr` payload is a non-nil pointer to a new `H`, which acts as the
+// [HeaderHooks] for all [Header] encoding and decoding. As these hooks are
+// used by [Header.Hash], the payload is also carried through to block hashes,
+// database storage and [Block] methods that copy their headers. Chains that
+// don't require a [Header] payload can register [NOOPHeaderHooks].
+//
+// Similarly, the `BPtr` payload is a non-nil pointer to a new `B`, which acts
+// as the [BlockBodyHooks] for encoding and decoding of all [Body] and [Block]
+// structs. The same payload is shared by a [Block] and the [Body] returned by
+// [Block.Body], and is therefore carried through database storage and the eth
+// wire protocol. Chains that don't require a body payload can register
+// [NOOPBlockBodyHooks].
+//
+// The payloads can be accessed via the [ExtraPayloads.FromHeader],
+// [ExtraPayloads.FromBody], [ExtraPayloads.FromBlock], and
+// [ExtraPayloads.FromStateAccount] methods of the accessor returned by
+// RegisterExtras.
+//
+// As the `SA` payload is part of the state root, its encoding can't be
+// versioned with [pseudo.RegisterVersions]; see [pseudo.RequireUnversioned].
+func RegisterExtras[
+	H any, HPtr interface {
+		HeaderHooks
+		*H
+	},
+	B any, BPtr interface {
+		BlockBodyHooks
+		*B
+	},
+	SA any,
+]() ExtraPayloads[HPtr, BPtr, SA] {
 	if registeredExtras != nil {
 		panic("re-registration of Extras")
 	}
-	var extra ExtraPayloads[SA]
+	pseudo.RequireUnversioned[SA]("state-account extras are part of the state root")
+
+	var extra ExtraPayloads[HPtr, BPtr, SA]
 	registeredExtras = &extraConstructors{
 		stateAccountType: func() string {
 			var x SA
 			return fmt.Sprintf("%T", x)
 		}(),
+		// The [ExtraPayloads] that we return is based on [HPtr,BPtr,SA], not
+		// [H,B,SA] so our constructors MUST match that. This guarantees that
+		// calls to the [HeaderHooks] and [BlockBodyHooks] methods will never be
+		// performed on a nil pointer.
+		newHeader:         pseudo.NewConstructor[H]().NewPointer, // i.e. non-nil HPtr
+		newBody:           pseudo.NewConstructor[B]().NewPointer, // i.e. non-nil BPtr
 		newStateAccount:   pseudo.NewConstructor[SA]().Zero,
 		cloneStateAccount: extra.cloneStateAccount,
+		hooks:             extra,
 	}
 	return extra
 }
@@ -67,9 +108,14 @@ func TestOnlyClearRegisteredExtras() {
 var registeredExtras *extraConstructors
 
 type extraConstructors struct {
-	stateAccountType  string
-	newStateAccount   func() *pseudo.Type
-	cloneStateAccount func(*StateAccountExtra) *StateAccountExtra
+	stateAccountType                    string
+	newHeader, newBody, newStateAccount func() *pseudo.Type
+	cloneStateAccount                   func(*StateAccountExtra) *StateAccountExtra
+	hooks                               interface {
+		hooksFromHeader(*Header) HeaderHooks
+		hooksFromBody(*Body) BlockBodyHooks
+		hooksFromBlock(*Block) BlockBodyHooks
+	}
 }
 
 func (e *StateAccountExtra) clone() *StateAccountExtra {
@@ -81,14 +127,90 @@ func (e *StateAccountExtra) clone() *StateAccountExtra {
 	}
 }
 
-// ExtraPayloads provides strongly typed access to the extra payload carried by
-// [StateAccount] structs. The only valid way to construct an instance is by a
-// call to [RegisterExtras].
-type ExtraPayloads[SA any] struct {
+// ExtraPayloads provides strongly typed access to the extra payloads carried by
+// [Header], [Body], [Block], and [StateAccount] structs. The only valid way to
+// construct an instance is by a call to [RegisterExtras].
+type ExtraPayloads[HPtr HeaderHooks, BPtr BlockBodyHooks, SA any] struct {
 	_ struct{} // make godoc show unexported fields so nobody tries to make their own instance ;)
 }
 
-func (ExtraPayloads[SA]) cloneStateAccount(s *StateAccountExtra) *StateAccountExtra {
+// FromHeader returns the Header's payload.
+func (ExtraPayloads[HPtr, BPtr, SA]) FromHeader(h *Header) HPtr {
+	return pseudo.MustNewValue[HPtr](h.extraPayload()).Get()
+}
+
+// SetOnHeader sets the Header's payload.
+func (ExtraPayloads[HPtr, BPtr, SA]) SetOnHeader(h *Header, val HPtr) {
+	h.extra = pseudo.From(val).Type
+}
+
+// hooksFromHeader is equivalent to FromHeader(), but returns an interface
+// instead of the concrete type implementing it; this allows it to be used in
+// non-generic code.
+func (e ExtraPayloads[HPtr, BPtr, SA]) hooksFromHeader(h *Header) HeaderHooks {
+	return e.FromHeader(h)
+}
+
+// extraPayload returns the Header's payload, lazily constructing a new one if
+// none has been set. It MUST only be called after [RegisterExtras].
+func (h *Header) extraPayload() *pseudo.Type {
+	if h.extra == nil {
+		h.extra = registeredExtras.newHeader()
+	}
+	return h.extra
+}
+
+// FromBody returns the Body's payload.
+func (ExtraPayloads[HPtr, BPtr, SA]) FromBody(b *Body) BPtr {
+	return pseudo.MustNewValue[BPtr](b.extraPayload()).Get()
+}
+
+// SetOnBody sets the Body's payload.
+func (ExtraPayloads[HPtr, BPtr, SA]) SetOnBody(b *Body, val BPtr) {
+	b.extra = pseudo.From(val).Type
+}
+
+// FromBlock returns the Block's payload, which is shared with the [Body]
+// returned by [Block.Body].
+func (ExtraPayloads[HPtr, BPtr, SA]) FromBlock(b *Block) BPtr {
+	return pseudo.MustNewValue[BPtr](b.extraPayload()).Get()
+}
+
+// SetOnBlock sets the Block's payload. As a [Block] is treated as immutable,
+// SetOnBlock MUST only be called before the Block is used elsewhere; prefer
+// [Block.WithBodyExtra] in concurrent contexts.
+func (ExtraPayloads[HPtr, BPtr, SA]) SetOnBlock(b *Block, val BPtr) {
+	b.extra = pseudo.From(val).Type
+	b.size = atomic.Value{} // size is dependent on the payload
+}
+
+// hooksFromBody is the [Body] equivalent of hooksFromHeader().
+func (e ExtraPayloads[HPtr, BPtr, SA]) hooksFromBody(b *Body) BlockBodyHooks {
+	return e.FromBody(b)
+}
+
+// hooksFromBlock is the [Block] equivalent of hooksFromHeader().
+func (e ExtraPayloads[HPtr, BPtr, SA]) hooksFromBlock(b *Block) BlockBodyHooks {
+	return e.FromBlock(b)
+}
+
+// extraPayload is equivalent to [Header.extraPayload].
+func (b *Body) extraPayload() *pseudo.Type {
+	if b.extra == nil {
+		b.extra = registeredExtras.newBody()
+	}
+	return b.extra
+}
+
+// extraPayload is equivalent to [Header.extraPayload].
+func (b *Block) extraPayload() *pseudo.Type {
+	if b.extra == nil {
+		b.extra = registeredExtras.newBody()
+	}
+	return b.extra
+}
+
+func (ExtraPayloads[HPtr, BPtr, SA]) cloneStateAccount(s *StateAccountExtra) *StateAccountExtra {
 	v := pseudo.MustNewValue[SA](s.t)
 	return &StateAccountExtra{
 		t: pseudo.From(v.Get()).Type,
@@ -96,7 +218,7 @@ func (ExtraPayloads[SA]) cloneStateAccount(s *StateAccountExtra) *StateAccountEx
 }
 
 // FromStateAccount returns the StateAccount's payload.
-func (ExtraPayloads[SA]) FromStateAccount(a *StateAccount) SA {
+func (ExtraPayloads[HPtr, BPtr, SA]) FromStateAccount(a *StateAccount) SA {
 	return pseudo.MustNewValue[SA](a.extra().payload()).Get()
 }
 
@@ -107,15 +229,68 @@ func (ExtraPayloads[SA]) FromStateAccount(a *StateAccount) SA {
 // shallow copy and that the *SA returned here will therefore be shared by all
 // copies. If this is not the desired behaviour, use
 // [StateAccount.Copy] or [ExtraPayloads.SetOnStateAccount].
-func (ExtraPayloads[SA]) PointerFromStateAccount(a *StateAccount) *SA {
+func (ExtraPayloads[HPtr, BPtr, SA]) PointerFromStateAccount(a *StateAccount) *SA {
 	return pseudo.MustPointerTo[SA](a.extra().payload()).Value.Get()
 }
 
 // SetOnStateAccount sets the StateAccount's payload.
-func (ExtraPayloads[SA]) SetOnStateAccount(a *StateAccount, val SA) {
+func (ExtraPayloads[HPtr, BPtr, SA]) SetOnStateAccount(a *StateAccount, val SA) {
 	a.extra().t = pseudo.From(val).Type
 }
 
+// TypeErasedExtra returns a copy of the StateAccount's extra payload, as would
+// be returned by [ExtraPayloads.FromStateAccount], but without type
+// information. It is intended for use by interfaces that can't carry the type
+// parameters of [ExtraPayloads]; it returns nil if no extras are registered.
+func (a *StateAccount) TypeErasedExtra() *pseudo.Type {
+	if registeredExtras == nil {
+		return nil
+	}
+	return registeredExtras.cloneStateAccount(&StateAccountExtra{t: a.extra().payload()}).t
+}
+
+// SetTypeErasedExtra is the inverse of [StateAccount.TypeErasedExtra]. It
+// panics if no extras are registered or if the payload's type differs from
+// the one registered with [RegisterExtras].
+func (a *StateAccount) SetTypeErasedExtra(t *pseudo.Type) {
+	if registeredExtras == nil {
+		panic("no extras registered")
+	}
+	a.Extra = registeredExtras.cloneStateAccount(&StateAccountExtra{t: t})
+}
+
+// FromGenesisAccount returns the genesis Account's payload, which is the
+// zero value if none was specified.
+func (ExtraPayloads[HPtr, BPtr, SA]) FromGenesisAccount(a *Account) SA {
+	if a.Extra == nil {
+		var zero SA
+		return zero
+	}
+	return pseudo.MustNewValue[SA](a.Extra.payload()).Get()
+}
+
+// SetOnGenesisAccount sets the genesis Account's payload, which is committed
+// alongside the rest of the account's allocation.
+func (ExtraPayloads[HPtr, BPtr, SA]) SetOnGenesisAccount(a *Account, val SA) {
+	a.Extra = &StateAccountExtra{
+		t: pseudo.From(val).Type,
+	}
+}
+
+// TypeErased returns a copy of the payload without type information, for use
+// with [StateAccount.SetTypeErasedExtra] and equivalent methods that accept a
+// [pseudo.Type]. It returns nil if no extras are registered.
+func (e *StateAccountExtra) TypeErased() *pseudo.Type {
+	switch r := registeredExtras; {
+	case r == nil:
+		return nil
+	case e == nil:
+		return r.newStateAccount()
+	default:
+		return r.cloneStateAccount(&StateAccountExtra{t: e.payload()}).t
+	}
+}
+
 // A StateAccountExtra carries the extra payload, if any, registered with
 // [RegisterExtras]. It SHOULD NOT be used directly; instead use the
 // [ExtraPayloads] accessor returned by RegisterExtras.
@@ -163,6 +338,8 @@ func (e *StateAccountExtra) Equal(f *StateAccountExtra) bool {
 var _ interface {
 	rlp.Encoder
 	rlp.Decoder
+	json.Marshaler
+	json.Unmarshaler
 	fmt.Formatter
 } = (*StateAccountExtra)(nil)
 
@@ -193,6 +370,30 @@ func (e *StateAccountExtra) DecodeRLP(s *rlp.Stream) error {
 	}
 }
 
+// MarshalJSON implements the [json.Marshaler] interface, using the JSON
+// encoding of the registered type.
+func (e *StateAccountExtra) MarshalJSON() ([]byte, error) {
+	switch r := registeredExtras; {
+	case r == nil:
+		return []byte("null"), nil
+	case e.t == nil:
+		e.t = r.newStateAccount()
+	}
+	return e.t.MarshalJSON()
+}
+
+// UnmarshalJSON implements the [json.Unmarshaler] interface. It returns an
+// error if no extras are registered.
+func (e *StateAccountExtra) UnmarshalJSON(b []byte) error {
+	switch r := registeredExtras; {
+	case r == nil:
+		return errors.New("unmarshalling StateAccount extra with no registered type")
+	case e.t == nil:
+		e.t = r.newStateAccount()
+	}
+	return e.t.UnmarshalJSON(b)
+}
+
 // Format implements the [fmt.Formatter] interface.
 func (e *StateAccountExtra) Format(s fmt.State, verb rune) {
 	var out string
@@ -17,6 +17,7 @@
 package types
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -46,7 +47,7 @@ func TestStateAccountRLP(t *testing.T) {
 	explicitFalseBoolean := test{
 		name: "explicit false-boolean extra",
 		register: func() {
-			RegisterExtras[bool]()
+			RegisterExtras[NOOPHeaderHooks, *NOOPHeaderHooks, NOOPBlockBodyHooks, *NOOPBlockBodyHooks, bool]()
 		},
 		acc: &StateAccount{
 			Nonce:    0x444444,
@@ -76,7 +77,7 @@ func TestStateAccountRLP(t *testing.T) {
 		{
 			name: "true-boolean extra",
 			register: func() {
-				RegisterExtras[bool]()
+				RegisterExtras[NOOPHeaderHooks, *NOOPHeaderHooks, NOOPBlockBodyHooks, *NOOPBlockBodyHooks, bool]()
 			},
 			acc: &StateAccount{
 				Nonce:    0x444444,
@@ -212,3 +213,84 @@ func repeatAsHash(x byte) (h common.Hash) {
 	}
 	return h
 }
+
+func TestTypeErasedStateAccountExtra(t *testing.T) {
+	acc := &StateAccount{}
+	require.Nil(t, acc.TypeErasedExtra(), "TypeErasedExtra() without registered extras")
+
+	TestOnlyClearRegisteredExtras()
+	t.Cleanup(TestOnlyClearRegisteredExtras)
+	payloads := RegisterExtras[NOOPHeaderHooks, *NOOPHeaderHooks, NOOPBlockBodyHooks, *NOOPBlockBodyHooks, []byte]()
+
+	require.Equal(t, []byte(nil), pseudo.MustNewValue[[]byte](acc.TypeErasedExtra()).Get(), "zero-value payload")
+
+	want := []byte{1, 2, 3}
+	acc.SetTypeErasedExtra(pseudo.From(want).Type)
+	require.Equal(t, want, payloads.FromStateAccount(acc), "FromStateAccount() after SetTypeErasedExtra()")
+
+	erased := acc.TypeErasedExtra()
+	payloads.SetOnStateAccount(acc, []byte{4})
+	require.Equal(t, want, pseudo.MustNewValue[[]byte](erased).Get(), "TypeErasedExtra() returns a copy")
+
+	require.Panics(t, func() { acc.SetTypeErasedExtra(pseudo.From(42).Type) }, "SetTypeErasedExtra() with incorrect type")
+}
+
+func TestGenesisAccountExtraJSON(t *testing.T) {
+	const withExtra = `{"balance": "0x1", "extra": 42}`
+
+	t.Run("unregistered", func(t *testing.T) {
+		TestOnlyClearRegisteredExtras()
+		var acc Account
+		require.Error(t, json.Unmarshal([]byte(withExtra), &acc), "json.Unmarshal() with extra but no registered type")
+		require.NoError(t, json.Unmarshal([]byte(`{"balance": "0x1"}`), &acc), "json.Unmarshal() without extra")
+		require.Nil(t, acc.Extra, "Account.Extra")
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		TestOnlyClearRegisteredExtras()
+		t.Cleanup(TestOnlyClearRegisteredExtras)
+		payloads := RegisterExtras[NOOPHeaderHooks, *NOOPHeaderHooks, NOOPBlockBodyHooks, *NOOPBlockBodyHooks, uint64]()
+
+		var acc Account
+		require.NoError(t, json.Unmarshal([]byte(withExtra), &acc), "json.Unmarshal()")
+		require.Equal(t, uint64(42), payloads.FromGenesisAccount(&acc), "FromGenesisAccount()")
+
+		payloads.SetOnGenesisAccount(&acc, 99)
+		buf, err := json.Marshal(acc)
+		require.NoError(t, err, "json.Marshal()")
+		require.JSONEq(t, `{"balance": "0x1", "extra": 99}`, string(buf), "json.Marshal()")
+	})
+}
+
+func TestStateAccountExtraVersioning(t *testing.T) {
+	type (
+		extraV0 struct{ Rent uint64 }
+		extraV1 struct{ RentPaid, RentDue uint64 }
+	)
+	migration := pseudo.Migrate(func(v extraV0) (extraV1, error) {
+		return extraV1{RentPaid: v.Rent}, nil
+	})
+	register := func() {
+		RegisterExtras[NOOPHeaderHooks, *NOOPHeaderHooks, NOOPBlockBodyHooks, *NOOPBlockBodyHooks, extraV1]()
+	}
+
+	t.Run("versions_after_extras", func(t *testing.T) {
+		TestOnlyClearRegisteredExtras()
+		t.Cleanup(TestOnlyClearRegisteredExtras)
+		pseudo.TestOnlyClearVersions()
+		t.Cleanup(pseudo.TestOnlyClearVersions)
+
+		register()
+		require.Panics(t, func() { pseudo.RegisterVersions[extraV1](migration) }, "pseudo.RegisterVersions() for state-account extra")
+	})
+
+	t.Run("extras_after_versions", func(t *testing.T) {
+		TestOnlyClearRegisteredExtras()
+		t.Cleanup(TestOnlyClearRegisteredExtras)
+		pseudo.TestOnlyClearVersions()
+		t.Cleanup(pseudo.TestOnlyClearVersions)
+
+		pseudo.RegisterVersions[extraV1](migration)
+		require.Panics(t, register, "RegisterExtras() with versioned state-account extra")
+	})
+}
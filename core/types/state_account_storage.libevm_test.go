@@ -71,38 +71,38 @@ func TestStateAccountExtraViaTrieStorage(t *testing.T) {
 		{
 			name: "true-boolean payload",
 			registerAndSetExtra: func(a *types.StateAccount) *types.StateAccount {
-				types.RegisterExtras[bool]().SetOnStateAccount(a, true)
+				types.RegisterExtras[types.NOOPHeaderHooks, *types.NOOPHeaderHooks, types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks, bool]().SetOnStateAccount(a, true)
 				return a
 			},
 			assertExtra: func(t *testing.T, sa *types.StateAccount) {
 				t.Helper()
-				assert.Truef(t, types.ExtraPayloads[bool]{}.FromStateAccount(sa), "")
+				assert.Truef(t, types.ExtraPayloads[*types.NOOPHeaderHooks, *types.NOOPBlockBodyHooks, bool]{}.FromStateAccount(sa), "")
 			},
 			wantTrieHash: trueBool,
 		},
 		{
 			name: "explicit false-boolean payload",
 			registerAndSetExtra: func(a *types.StateAccount) *types.StateAccount {
-				p := types.RegisterExtras[bool]()
+				p := types.RegisterExtras[types.NOOPHeaderHooks, *types.NOOPHeaderHooks, types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks, bool]()
 				p.SetOnStateAccount(a, false) // the explicit part
 				return a
 			},
 			assertExtra: func(t *testing.T, sa *types.StateAccount) {
 				t.Helper()
-				assert.Falsef(t, types.ExtraPayloads[bool]{}.FromStateAccount(sa), "")
+				assert.Falsef(t, types.ExtraPayloads[*types.NOOPHeaderHooks, *types.NOOPBlockBodyHooks, bool]{}.FromStateAccount(sa), "")
 			},
 			wantTrieHash: falseBool,
 		},
 		{
 			name: "implicit false-boolean payload",
 			registerAndSetExtra: func(a *types.StateAccount) *types.StateAccount {
-				types.RegisterExtras[bool]()
+				types.RegisterExtras[types.NOOPHeaderHooks, *types.NOOPHeaderHooks, types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks, bool]()
 				// Note that `a` is reflected, unchanged (the implicit part).
 				return a
 			},
 			assertExtra: func(t *testing.T, sa *types.StateAccount) {
 				t.Helper()
-				assert.Falsef(t, types.ExtraPayloads[bool]{}.FromStateAccount(sa), "")
+				assert.Falsef(t, types.ExtraPayloads[*types.NOOPHeaderHooks, *types.NOOPBlockBodyHooks, bool]{}.FromStateAccount(sa), "")
 			},
 			wantTrieHash: falseBool,
 		},
@@ -110,12 +110,12 @@ func TestStateAccountExtraViaTrieStorage(t *testing.T) {
 			name: "arbitrary payload",
 			registerAndSetExtra: func(a *types.StateAccount) *types.StateAccount {
 				p := arbitraryPayload{arbitraryData}
-				types.RegisterExtras[arbitraryPayload]().SetOnStateAccount(a, p)
+				types.RegisterExtras[types.NOOPHeaderHooks, *types.NOOPHeaderHooks, types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks, arbitraryPayload]().SetOnStateAccount(a, p)
 				return a
 			},
 			assertExtra: func(t *testing.T, sa *types.StateAccount) {
 				t.Helper()
-				got := types.ExtraPayloads[arbitraryPayload]{}.FromStateAccount(sa)
+				got := types.ExtraPayloads[*types.NOOPHeaderHooks, *types.NOOPBlockBodyHooks, arbitraryPayload]{}.FromStateAccount(sa)
 				assert.Equalf(t, arbitraryPayload{arbitraryData}, got, "")
 			},
 			wantTrieHash: arbitrary,
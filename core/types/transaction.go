@@ -207,7 +207,11 @@ func (tx *Transaction) decodeTyped(b []byte) (TxData, error) {
 	case BlobTxType:
 		inner = new(BlobTx)
 	default:
-		return nil, ErrTxTypeNotSupported
+		c, err := newCustomTxData(b[0]) // libevm
+		if err != nil {
+			return nil, err
+		}
+		inner = c
 	}
 	err := inner.decode(b[1:])
 	return inner, err
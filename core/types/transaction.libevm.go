@@ -0,0 +1,285 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/libevm/testonly"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// CustomTxData is the exported equivalent of [TxData], implemented by all
+// transaction types registered with [RegisterTxType]. All methods have the same
+// semantics as their unexported [TxData] counterparts.
+//
+// The EIP-2718 payload of a custom transaction (i.e. everything after the type
+// byte) is the RLP encoding of the CustomTxData, and its JSON representation is
+// that of the CustomTxData with the addition of "type" and "hash" fields. Both
+// MAY be modified by implementing the respective [rlp] and [json] interfaces.
+type CustomTxData interface {
+	Copy() CustomTxData
+
+	ChainID() *big.Int
+	AccessList() AccessList
+	Data() []byte
+	Gas() uint64
+	GasPrice() *big.Int
+	GasTipCap() *big.Int
+	GasFeeCap() *big.Int
+	Value() *big.Int
+	Nonce() uint64
+	To() *common.Address
+
+	RawSignatureValues() (v, r, s *big.Int)
+	SetSignatureValues(chainID, v, r, s *big.Int)
+	EffectiveGasPrice(dst *big.Int, baseFee *big.Int) *big.Int
+
+	// SigHash returns the hash to be signed by the sender, as returned by
+	// [Signer.Hash]. The signature values are expected to follow the same
+	// convention as other typed transactions, i.e. with a V of 0 or 1.
+	SigHash(chainID *big.Int) common.Hash
+}
+
+// RegisterTxType registers `TPtr` as the [CustomTxData] of the EIP-2718
+// transaction type `txType`, which MUST be neither a geth-native type nor
+// greater than 0x7f. It is expected to be called in an `init()` function and
+// MUST NOT be called more than once for the same `txType`.
+//
+// Once registered, transactions of the type are supported by RLP and JSON
+// encoding and decoding, by all [Signer] implementations that support
+// [AccessListTxType], and by receipt encoding and decoding. They can be
+// constructed and inspected via the methods of the returned [RegisteredTxType].
+// Unlike with native types, the chain ID of a custom transaction MUST be set,
+// to that of the [Signer], before signing; signers return an error wrapping
+// [ErrInvalidChainId] if it is nil, zero, or otherwise different.
+func RegisterTxType[T any, TPtr interface {
+	CustomTxData
+	*T
+}](txType byte) RegisteredTxType[TPtr] {
+	switch txType {
+	case LegacyTxType, AccessListTxType, DynamicFeeTxType, BlobTxType:
+		panic(fmt.Sprintf("registration of native transaction type %#x", txType))
+	}
+	if txType > 0x7f {
+		panic(fmt.Sprintf("registration of invalid EIP-2718 transaction type %#x", txType))
+	}
+	if _, ok := registeredTxTypes[txType]; ok {
+		panic(fmt.Sprintf("re-registration of transaction type %#x", txType))
+	}
+	if registeredTxTypes == nil {
+		registeredTxTypes = make(map[byte]func() CustomTxData)
+	}
+	registeredTxTypes[txType] = func() CustomTxData {
+		return TPtr(new(T))
+	}
+	return RegisteredTxType[TPtr]{txType}
+}
+
+// TestOnlyClearRegisteredTxTypes clears all types previously passed to
+// [RegisterTxType]. It panics if called from a non-testing call stack.
+//
+// In tests it SHOULD be called before every call to [RegisterTxType] and then
+// defer-called afterwards, either directly or via testing.TB.Cleanup().
+func TestOnlyClearRegisteredTxTypes() {
+	testonly.OrPanic(func() {
+		registeredTxTypes = nil
+	})
+}
+
+var registeredTxTypes map[byte]func() CustomTxData
+
+// A RegisteredTxType is returned by [RegisterTxType] to construct and inspect
+// transactions of the registered type.
+type RegisteredTxType[TPtr CustomTxData] struct {
+	txType byte
+}
+
+// Type returns the EIP-2718 transaction type passed to [RegisterTxType].
+func (r RegisteredTxType[TPtr]) Type() byte {
+	return r.txType
+}
+
+// NewTx is the [CustomTxData] equivalent of the package-level [NewTx].
+func (r RegisteredTxType[TPtr]) NewTx(data TPtr) *Transaction {
+	return NewTx(&customTx{r.txType, data})
+}
+
+// FromTx returns the data of the [Transaction] and true if, and only if, it is
+// of the registered type. The returned value MUST NOT be modified.
+func (r RegisteredTxType[TPtr]) FromTx(tx *Transaction) (TPtr, bool) {
+	if c, ok := tx.inner.(*customTx); ok && c.typ == r.txType {
+		d, ok := c.payload.(TPtr)
+		return d, ok
+	}
+	var zero TPtr
+	return zero, false
+}
+
+// CustomData returns the data of the [Transaction] and true if, and only if,
+// it is of a type registered with [RegisterTxType]. The returned value MUST NOT
+// be modified.
+func (tx *Transaction) CustomData() (CustomTxData, bool) {
+	if c, ok := tx.inner.(*customTx); ok {
+		return c.payload, true
+	}
+	return nil, false
+}
+
+// newCustomTxData returns a new, empty [customTx] of the specified type, or an
+// error if the type is unregistered.
+func newCustomTxData(txType byte) (*customTx, error) {
+	fn, ok := registeredTxTypes[txType]
+	if !ok {
+		return nil, ErrTxTypeNotSupported
+	}
+	return &customTx{txType, fn()}, nil
+}
+
+// isCustomTxType returns whether the type was registered with [RegisterTxType].
+func isCustomTxType(txType byte) bool {
+	_, ok := registeredTxTypes[txType]
+	return ok
+}
+
+// customTx adapts a [CustomTxData] into a [TxData].
+type customTx struct {
+	typ     byte
+	payload CustomTxData
+}
+
+var _ TxData = (*customTx)(nil)
+
+func (c *customTx) txType() byte { return c.typ }
+func (c *customTx) copy() TxData { return &customTx{c.typ, c.payload.Copy()} }
+
+func (c *customTx) chainID() *big.Int      { return c.payload.ChainID() }
+func (c *customTx) accessList() AccessList { return c.payload.AccessList() }
+func (c *customTx) data() []byte           { return c.payload.Data() }
+func (c *customTx) gas() uint64            { return c.payload.Gas() }
+func (c *customTx) gasPrice() *big.Int     { return c.payload.GasPrice() }
+func (c *customTx) gasTipCap() *big.Int    { return c.payload.GasTipCap() }
+func (c *customTx) gasFeeCap() *big.Int    { return c.payload.GasFeeCap() }
+func (c *customTx) value() *big.Int        { return c.payload.Value() }
+func (c *customTx) nonce() uint64          { return c.payload.Nonce() }
+func (c *customTx) to() *common.Address    { return c.payload.To() }
+
+func (c *customTx) rawSignatureValues() (v, r, s *big.Int) {
+	return c.payload.RawSignatureValues()
+}
+
+func (c *customTx) setSignatureValues(chainID, v, r, s *big.Int) {
+	c.payload.SetSignatureValues(chainID, v, r, s)
+}
+
+func (c *customTx) effectiveGasPrice(dst *big.Int, baseFee *big.Int) *big.Int {
+	return c.payload.EffectiveGasPrice(dst, baseFee)
+}
+
+// checkChainID returns an error wrapping [ErrInvalidChainId] if the chain ID of
+// the [CustomTxData] is nil or differs from that of the signer. Unlike native
+// transaction types, a zero chain ID is not accepted by any signer method.
+func (c *customTx) checkChainID(signer *big.Int) error {
+	if id := c.chainID(); id == nil || id.Cmp(signer) != 0 {
+		return fmt.Errorf("%w: have %d want %d", ErrInvalidChainId, id, signer)
+	}
+	return nil
+}
+
+func (c *customTx) encode(b *bytes.Buffer) error {
+	return rlp.Encode(b, c.payload)
+}
+
+// EncodeRLP implements the [rlp.Encoder] interface, encoding only the payload,
+// as with encode(). This is required by [Transaction.Hash], which hashes the
+// type-prefixed RLP encoding of the inner [TxData].
+func (c *customTx) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, c.payload)
+}
+
+func (c *customTx) decode(input []byte) error {
+	return rlp.DecodeBytes(input, c.payload)
+}
+
+// marshalJSON returns the JSON encoding of the [CustomTxData] with the addition
+// of the "type" and "hash" fields of the [Transaction].
+func (c *customTx) marshalJSON(tx *Transaction) ([]byte, error) {
+	buf, err := json.Marshal(c.payload)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(buf, &fields); err != nil {
+		return nil, fmt.Errorf("JSON encoding of %T MUST be an object: %v", c.payload, err)
+	}
+	for k, v := range map[string]any{
+		"type": hexutil.Uint64(c.typ),
+		"hash": tx.Hash(),
+	} {
+		if fields[k], err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// unmarshalCustomJSON decodes the JSON into tx if, and only if, its "type" field
+// was registered with [RegisterTxType], in which case it returns true. All other
+// JSON, including that which is invalid, is left to the default decoding, which
+// is responsible for returning errors.
+func (tx *Transaction) unmarshalCustomJSON(input []byte) (bool, error) {
+	if len(registeredTxTypes) == 0 {
+		return false, nil
+	}
+	var dec struct {
+		Type hexutil.Uint64 `json:"type"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil || dec.Type > 0xff {
+		return false, nil
+	}
+	c, err := newCustomTxData(byte(dec.Type))
+	if err != nil {
+		return false, nil
+	}
+	if err := c.unmarshalJSON(input); err != nil {
+		return true, err
+	}
+	tx.setDecoded(c, 0)
+	return true, nil
+}
+
+// unmarshalJSON is the inverse of marshalJSON(), except that it ignores the
+// "type" and "hash" fields.
+func (c *customTx) unmarshalJSON(input []byte) error {
+	if err := json.Unmarshal(input, c.payload); err != nil {
+		return err
+	}
+	v, r, s := c.payload.RawSignatureValues()
+	if v == nil || r == nil || s == nil {
+		return fmt.Errorf("missing signature values in %T", c.payload)
+	}
+	if v.Sign() != 0 || r.Sign() != 0 || s.Sign() != 0 {
+		return sanityCheckSignature(v, r, s, false)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm/ethtest"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const memoTxType = 0x7e
+
+// memoTx is a [types.CustomTxData] that carries an arbitrary memo string.
+type memoTx struct {
+	ChainId   *big.Int        `json:"chainId"`
+	Nonce_    uint64          `json:"nonce"`
+	Price     *big.Int        `json:"price"`
+	GasLimit  uint64          `json:"gas"`
+	Recipient *common.Address `json:"to" rlp:"nil"`
+	Amount    *big.Int        `json:"value"`
+	Payload   []byte          `json:"input"`
+	Memo      string          `json:"memo"`
+	V, R, S   *big.Int
+}
+
+var _ types.CustomTxData = (*memoTx)(nil)
+
+func (tx *memoTx) Copy() types.CustomTxData {
+	cp := *tx
+	cp.Payload = common.CopyBytes(tx.Payload)
+	for _, x := range []**big.Int{&cp.ChainId, &cp.Price, &cp.Amount, &cp.V, &cp.R, &cp.S} {
+		if *x != nil {
+			*x = new(big.Int).Set(*x)
+		}
+	}
+	if tx.Recipient != nil {
+		to := *tx.Recipient
+		cp.Recipient = &to
+	}
+	return &cp
+}
+
+func (tx *memoTx) ChainID() *big.Int            { return tx.ChainId }
+func (tx *memoTx) AccessList() types.AccessList { return nil }
+func (tx *memoTx) Data() []byte                 { return tx.Payload }
+func (tx *memoTx) Gas() uint64                  { return tx.GasLimit }
+func (tx *memoTx) GasPrice() *big.Int           { return tx.Price }
+func (tx *memoTx) GasTipCap() *big.Int          { return tx.Price }
+func (tx *memoTx) GasFeeCap() *big.Int          { return tx.Price }
+func (tx *memoTx) Value() *big.Int              { return tx.Amount }
+func (tx *memoTx) Nonce() uint64                { return tx.Nonce_ }
+func (tx *memoTx) To() *common.Address          { return tx.Recipient }
+
+func (tx *memoTx) RawSignatureValues() (v, r, s *big.Int) {
+	return tx.V, tx.R, tx.S
+}
+
+func (tx *memoTx) SetSignatureValues(chainID, v, r, s *big.Int) {
+	tx.ChainId, tx.V, tx.R, tx.S = chainID, v, r, s
+}
+
+// EffectiveGasPrice deliberately differs from that of native transaction types
+// so its use can be detected.
+func (tx *memoTx) EffectiveGasPrice(dst, baseFee *big.Int) *big.Int {
+	return dst.Add(tx.Price, baseFee)
+}
+
+func (tx *memoTx) SigHash(chainID *big.Int) common.Hash {
+	buf, err := rlp.EncodeToBytes([]any{
+		chainID, tx.Nonce_, tx.Price, tx.GasLimit, tx.Recipient, tx.Amount, tx.Payload, tx.Memo,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256Hash([]byte{memoTxType}, buf)
+}
+
+func TestRegisterTxType(t *testing.T) {
+	types.TestOnlyClearRegisteredTxTypes()
+	t.Cleanup(types.TestOnlyClearRegisteredTxTypes)
+	memoType := types.RegisterTxType[memoTx](memoTxType)
+	require.Equal(t, byte(memoTxType), memoType.Type(), "RegisterTxType().Type()")
+
+	rng := ethtest.NewPseudoRand(314159)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err, "crypto.GenerateKey()")
+	chainID := big.NewInt(43114)
+	signer := types.LatestSignerForChainID(chainID)
+
+	data := &memoTx{
+		ChainId:   chainID,
+		Nonce_:    rng.Uint64(),
+		Price:     big.NewInt(1e9),
+		GasLimit:  21_000,
+		Recipient: rng.AddressPtr(),
+		Amount:    big.NewInt(42),
+		Payload:   rng.Bytes(8),
+		Memo:      "Hello, custom world!",
+	}
+	tx, err := types.SignTx(memoType.NewTx(data), signer, key)
+	require.NoError(t, err, "types.SignTx(RegisteredTxType.NewTx(...))")
+	require.Equal(t, uint8(memoTxType), tx.Type(), "Transaction.Type()")
+
+	t.Run("accessors", func(t *testing.T) {
+		got, ok := memoType.FromTx(tx)
+		require.True(t, ok, "RegisteredTxType.FromTx()")
+		assert.Equal(t, data.Memo, got.Memo, "RegisteredTxType.FromTx().Memo")
+		assert.Equal(t, chainID, got.ChainId, "RegisteredTxType.FromTx().ChainId")
+
+		_, ok = tx.CustomData()
+		assert.True(t, ok, "Transaction.CustomData()")
+		_, ok = types.NewTx(&types.LegacyTx{}).CustomData()
+		assert.False(t, ok, "Transaction.CustomData() on native transaction type")
+		_, ok = memoType.FromTx(types.NewTx(&types.LegacyTx{}))
+		assert.False(t, ok, "RegisteredTxType.FromTx() on native transaction type")
+
+		assert.Equal(t, data.Nonce_, tx.Nonce(), "Transaction.Nonce()")
+		assert.Equal(t, data.Recipient, tx.To(), "Transaction.To()")
+		assert.Equal(t, data.Payload, tx.Data(), "Transaction.Data()")
+		assert.True(t, tx.Protected(), "Transaction.Protected()")
+	})
+
+	wantSender := crypto.PubkeyToAddress(key.PublicKey)
+	assertSender := func(t *testing.T, tx *types.Transaction) {
+		t.Helper()
+		got, err := types.Sender(signer, tx)
+		require.NoError(t, err, "types.Sender()")
+		assert.Equal(t, wantSender, got, "types.Sender()")
+	}
+	t.Run("signer", func(t *testing.T) {
+		assertSender(t, tx)
+
+		other := types.LatestSignerForChainID(big.NewInt(1))
+		_, err := types.Sender(other, tx)
+		assert.ErrorIs(t, err, types.ErrInvalidChainId, "types.Sender() with different chain ID")
+
+		_, err = types.Sender(types.HomesteadSigner{}, tx)
+		assert.ErrorIs(t, err, types.ErrTxTypeNotSupported, "types.Sender() with pre-EIP-2930 signer")
+	})
+
+	for _, tt := range []struct {
+		name    string
+		chainID *big.Int
+	}{
+		{name: "nil_chain_id", chainID: nil},
+		{name: "zero_chain_id", chainID: big.NewInt(0)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			unsigned := data.Copy().(*memoTx)
+			unsigned.ChainId = tt.chainID
+			_, err := types.SignTx(memoType.NewTx(unsigned), signer, key)
+			assert.ErrorIs(t, err, types.ErrInvalidChainId, "types.SignTx()")
+
+			got, ok := memoType.FromTx(tx)
+			require.True(t, ok, "RegisteredTxType.FromTx()")
+			signed := got.Copy().(*memoTx)
+			signed.ChainId = tt.chainID
+			_, err = types.Sender(signer, memoType.NewTx(signed))
+			assert.ErrorIs(t, err, types.ErrInvalidChainId, "types.Sender()")
+		})
+	}
+
+	t.Run("binary_round_trip", func(t *testing.T) {
+		buf, err := tx.MarshalBinary()
+		require.NoError(t, err, "Transaction.MarshalBinary()")
+		require.Equal(t, byte(memoTxType), buf[0], "first byte of Transaction.MarshalBinary()")
+
+		got := new(types.Transaction)
+		require.NoError(t, got.UnmarshalBinary(buf), "Transaction.UnmarshalBinary()")
+		assert.Equal(t, tx.Hash(), got.Hash(), "Transaction.Hash() after round trip")
+		assertSender(t, got)
+
+		var fromRLP types.Transaction
+		enc, err := rlp.EncodeToBytes(tx)
+		require.NoError(t, err, "rlp.EncodeToBytes(Transaction)")
+		require.NoError(t, rlp.DecodeBytes(enc, &fromRLP), "rlp.DecodeBytes(..., Transaction)")
+		assert.Equal(t, tx.Hash(), fromRLP.Hash(), "Transaction.Hash() after RLP round trip")
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		buf, err := tx.MarshalBinary()
+		require.NoError(t, err, "Transaction.MarshalBinary()")
+		assert.Equal(t, crypto.Keccak256Hash(buf), tx.Hash(), "Transaction.Hash() MUST be the hash of Transaction.MarshalBinary()")
+
+		other := data.Copy().(*memoTx)
+		other.Memo = "Goodbye, custom world!"
+		otherTx, err := types.SignTx(memoType.NewTx(other), signer, key)
+		require.NoError(t, err, "types.SignTx(RegisteredTxType.NewTx(...))")
+		assert.NotEqual(t, tx.Hash(), otherTx.Hash(), "Transaction.Hash() of different payloads")
+	})
+
+	t.Run("JSON_round_trip", func(t *testing.T) {
+		buf, err := json.Marshal(tx)
+		require.NoError(t, err, "json.Marshal(Transaction)")
+
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(buf, &fields), "json.Unmarshal(..., map)")
+		assert.Equal(t, "0x7e", fields["type"], `JSON "type" field`)
+		assert.Equal(t, tx.Hash().Hex(), fields["hash"], `JSON "hash" field`)
+		assert.Equal(t, data.Memo, fields["memo"], `JSON "memo" field`)
+
+		got := new(types.Transaction)
+		require.NoError(t, json.Unmarshal(buf, got), "json.Unmarshal(..., Transaction)")
+		assert.Equal(t, tx.Hash(), got.Hash(), "Transaction.Hash() after round trip")
+		assertSender(t, got)
+	})
+
+	t.Run("receipt", func(t *testing.T) {
+		r := &types.Receipt{
+			Type:              memoTxType,
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21_000,
+			Logs:              []*types.Log{},
+		}
+		buf, err := r.MarshalBinary()
+		require.NoError(t, err, "Receipt.MarshalBinary()")
+
+		got := new(types.Receipt)
+		require.NoError(t, got.UnmarshalBinary(buf), "Receipt.UnmarshalBinary()")
+		assert.Equal(t, r.Type, got.Type, "Receipt.Type after round trip")
+		assert.Equal(t, r.Status, got.Status, "Receipt.Status after round trip")
+	})
+
+	t.Run("unregistered", func(t *testing.T) {
+		buf, err := tx.MarshalBinary()
+		require.NoError(t, err, "Transaction.MarshalBinary()")
+		buf[0] = memoTxType - 1
+		assert.ErrorIs(t, new(types.Transaction).UnmarshalBinary(buf), types.ErrTxTypeNotSupported)
+	})
+
+	t.Run("invalid_registration", func(t *testing.T) {
+		for _, typ := range []byte{types.LegacyTxType, types.BlobTxType, memoTxType, 0x80} {
+			assert.Panicsf(t, func() {
+				types.RegisterTxType[memoTx](typ)
+			}, "RegisterTxType(%#x)", typ)
+		}
+	})
+}
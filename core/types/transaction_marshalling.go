@@ -86,6 +86,9 @@ func (tx *Transaction) MarshalJSON() ([]byte, error) {
 
 	// Other fields are set conditionally depending on tx type.
 	switch itx := tx.inner.(type) {
+	case *customTx: // libevm
+		return itx.marshalJSON(tx)
+
 	case *LegacyTx:
 		enc.Nonce = (*hexutil.Uint64)(&itx.Nonce)
 		enc.To = tx.To()
@@ -159,6 +162,10 @@ func (tx *Transaction) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON unmarshals from JSON.
 func (tx *Transaction) UnmarshalJSON(input []byte) error {
+	if ok, err := tx.unmarshalCustomJSON(input); ok { // libevm
+		return err
+	}
+
 	var dec txJSON
 	err := json.Unmarshal(input, &dec)
 	if err != nil {
@@ -337,7 +337,16 @@ func (s eip2930Signer) Sender(tx *Transaction) (common.Address, error) {
 		// id, add 27 to become equivalent to unprotected Homestead signatures.
 		V = new(big.Int).Add(V, big.NewInt(27))
 	default:
-		return common.Address{}, ErrTxTypeNotSupported
+		//libevm:start
+		c, ok := tx.inner.(*customTx)
+		if !ok {
+			return common.Address{}, ErrTxTypeNotSupported
+		}
+		if err := c.checkChainID(s.chainId); err != nil {
+			return common.Address{}, err
+		}
+		return recoverPlain(s.Hash(tx), R, S, new(big.Int).Add(V, big.NewInt(27)), true)
+		//libevm:end
 	}
 	if tx.ChainId().Cmp(s.chainId) != 0 {
 		return common.Address{}, fmt.Errorf("%w: have %d want %d", ErrInvalidChainId, tx.ChainId(), s.chainId)
@@ -357,6 +366,12 @@ func (s eip2930Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *bi
 		}
 		R, S, _ = decodeSignature(sig)
 		V = big.NewInt(int64(sig[64]))
+	case *customTx: // libevm
+		if err := txdata.checkChainID(s.chainId); err != nil {
+			return nil, nil, nil, err
+		}
+		R, S, _ = decodeSignature(sig)
+		V = big.NewInt(int64(sig[64]))
 	default:
 		return nil, nil, nil, ErrTxTypeNotSupported
 	}
@@ -383,6 +398,9 @@ func (s eip2930Signer) Hash(tx *Transaction) common.Hash {
 				tx.AccessList(),
 			})
 	default:
+		if c, ok := tx.CustomData(); ok { // libevm
+			return c.SigHash(s.chainId)
+		}
 		// This _should_ not happen, but in case someone sends in a bad
 		// json struct via RPC, it's probably more prudent to return an
 		// empty hash instead of killing the node with a panic
@@ -17,6 +17,8 @@
 package vm
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/big"
 
@@ -83,7 +85,21 @@ func (t CallType) String() string {
 // regular types.
 func (args *evmCallArgs) run(p PrecompiledContract, input []byte, suppliedGas uint64) (ret []byte, remainingGas uint64, err error) {
 	if p, ok := p.(statefulPrecompile); ok {
-		return p(args.env(), input, suppliedGas)
+		env := args.env()
+		env.self.Gas = suppliedGas
+		ret, remainingGas, err = p(env, input, suppliedGas)
+
+		// Gas consumed via the environment is accounted for, regardless of the
+		// amount returned by the precompile.
+		if g := env.self.Gas; remainingGas > g {
+			remainingGas = g
+		}
+		// Equivalent to the handling of insufficient gas by the native
+		// RunPrecompiledContract().
+		if errors.Is(err, ErrOutOfGas) {
+			return nil, 0, err
+		}
+		return ret, remainingGas, err
 	}
 	// Gas consumption for regular precompiles was already handled by the native
 	// RunPrecompiledContract(), which called this method.
@@ -124,6 +140,10 @@ func (p statefulPrecompile) Run([]byte) ([]byte, error) {
 // precompiled contract is being run; and (b) a means of calling other
 // contracts.
 type PrecompileEnvironment interface {
+	// Context returns the context observed by execution; see
+	// [EVM.SetExecutionContext]. Long-running precompiles SHOULD return an
+	// error once it is done.
+	Context() context.Context
 	ChainConfig() *params.ChainConfig
 	Rules() params.Rules
 	ReadOnly() bool
@@ -133,6 +153,28 @@ type PrecompileEnvironment interface {
 	ReadOnlyState() libevm.StateReader
 	Addresses() *libevm.AddressContext
 	IncomingCallType() CallType
+	// Gas returns the gas remaining to the precompile, which is initially the
+	// amount supplied to it. The `remainingGas` returned by a precompile is
+	// capped at this value, and is always zero if the precompile returns an
+	// error that [errors.Is] [ErrOutOfGas].
+	Gas() uint64
+	// UseGas consumes the specified amount of gas, returning [ErrOutOfGas],
+	// after consuming all remaining gas, if there is insufficient gas.
+	UseGas(uint64) error
+	// UseAccountAccessGas and UseStorageAccessGas consume gas for access to
+	// the respective state, according to EIP-2929 (i.e. warm vs cold access),
+	// and add the address (and slot) to the access list. They consume no gas
+	// before Berlin. Errors are as for UseGas.
+	UseAccountAccessGas(common.Address) error
+	UseStorageAccessGas(_ common.Address, slot common.Hash) error
+	// AddRefund is equivalent to [StateDB.AddRefund], except that it returns
+	// [ErrWriteProtection] if ReadOnly().
+	AddRefund(uint64) error
+
+	// Value returns the value transferred to the precompile, equivalent to the
+	// CALLVALUE op code; i.e. it is inherited if DelegateCall()ed and zero if
+	// StaticCall()ed.
+	Value() *uint256.Int
 
 	BlockHeader() (types.Header, error)
 	BlockNumber() *big.Int
@@ -142,6 +184,27 @@ type PrecompileEnvironment interface {
 	// removed and automatically determined according to the type of call that
 	// invoked the precompile.
 	Call(addr common.Address, input []byte, gas uint64, value *uint256.Int, _ ...CallOption) (ret []byte, gasRemaining uint64, _ error)
+	// CallCode, DelegateCall, and StaticCall are the respective equivalents of
+	// Call. As with the op codes of the same names, code run via CallCode or
+	// DelegateCall modifies the storage of the precompile's own address, and
+	// code run via StaticCall is read-only. DelegateCall additionally
+	// propagates the precompile's caller and value.
+	//
+	// The [WithUNSAFECallerAddressProxying] option is only supported by Call.
+	CallCode(addr common.Address, input []byte, gas uint64, value *uint256.Int, _ ...CallOption) (ret []byte, gasRemaining uint64, _ error)
+	DelegateCall(addr common.Address, input []byte, gas uint64, _ ...CallOption) (ret []byte, gasRemaining uint64, _ error)
+	StaticCall(addr common.Address, input []byte, gas uint64, _ ...CallOption) (ret []byte, gasRemaining uint64, _ error)
+
+	// Create and Create2 are equivalent to [EVM.Create] and [EVM.Create2]
+	// except that the `caller` argument is removed and is always the address
+	// of the precompile (or of the calling contract if DelegateCall()ed), the
+	// nonce of which is incremented. They return [ErrWriteProtection] if
+	// ReadOnly() and, once Shanghai is active, [ErrMaxInitCodeSizeExceeded]
+	// if the code is too large. As with Call, no gas is charged for the
+	// equivalent op codes themselves, which is the responsibility of the
+	// precompile.
+	Create(code []byte, gas uint64, value *uint256.Int) (ret []byte, contractAddr common.Address, gasRemaining uint64, _ error)
+	Create2(code []byte, gas uint64, value *uint256.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, gasRemaining uint64, _ error)
 }
 
 func (args *evmCallArgs) env() *environment {
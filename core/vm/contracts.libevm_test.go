@@ -17,6 +17,8 @@ package vm_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -30,6 +32,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -487,6 +490,139 @@ func TestCanCreateContract(t *testing.T) {
 	}
 }
 
+func TestCanDeployCode(t *testing.T) {
+	rng := ethtest.NewPseudoRand(161803)
+	origin := rng.Address()
+	caller := rng.Address()
+
+	const extraGas = 5000
+	errForbidden := errors.New("forbidden op code")
+	var gotAddrs *libevm.AddressContext
+	hooks := &hookstest.Stub{
+		CanDeployCodeFn: func(cc *libevm.AddressContext, code []byte, gas uint64, _ libevm.StateReader) (uint64, error) {
+			gotAddrs = cc
+			switch {
+			case bytes.Contains(code, []byte{byte(vm.SELFDESTRUCT)}):
+				return gas, errForbidden
+			case bytes.Contains(code, []byte{byte(vm.GAS)}):
+				return gas + extraGas, nil
+			}
+			return gas - extraGas, nil
+		},
+	}
+
+	// initCode returns init code that deploys the single byte of runtime code.
+	initCode := func(deploy vm.OpCode) []byte {
+		return convertBytes[vm.OpCode, byte]([]vm.OpCode{
+			vm.PUSH1, deploy,
+			vm.PUSH1, 0,
+			vm.MSTORE8,
+			vm.PUSH1, 1,
+			vm.PUSH1, 0,
+			vm.RETURN,
+		})
+	}
+
+	const gasLimit = 1e6
+	create := func(t *testing.T, deploy vm.OpCode) (*state.StateDB, common.Address, uint64, error) {
+		t.Helper()
+		state, evm := ethtest.NewZeroEVM(t)
+		evm.TxContext.Origin = origin
+		_, addr, gas, err := evm.Create(vm.AccountRef(caller), initCode(deploy), gasLimit, uint256.NewInt(0))
+		return state, addr, gas, err
+	}
+
+	// Establish the baseline gas consumption without the hook.
+	_, _, baseline, err := create(t, vm.STOP)
+	require.NoError(t, err, "Create() without hooks")
+
+	hooks.Register(t)
+
+	t.Run("allowed", func(t *testing.T) {
+		state, addr, gas, err := create(t, vm.STOP)
+		require.NoError(t, err, "Create()")
+		assert.Equal(t, []byte{byte(vm.STOP)}, state.GetCode(addr), "deployed code")
+		assert.Equal(t, baseline-extraGas, gas, "gas remaining after extra charge by hook")
+		assert.Equal(t, &libevm.AddressContext{Origin: origin, Caller: caller, Self: addr}, gotAddrs, "AddressContext received by hook")
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		state, addr, gas, err := create(t, vm.SELFDESTRUCT)
+		require.ErrorIs(t, err, errForbidden, "Create()")
+		assert.Empty(t, state.GetCode(addr), "deployed code")
+		assert.Zero(t, gas, "gas remaining after rejection by hook")
+	})
+
+	t.Run("gas_increased", func(t *testing.T) {
+		state, addr, gas, err := create(t, vm.GAS)
+		require.ErrorContains(t, err, "more than the", "Create() when hook increases gas")
+		assert.Empty(t, state.GetCode(addr), "deployed code")
+		assert.Zero(t, gas, "gas remaining after hook increased gas")
+	})
+}
+
+func TestContractAddressOverride(t *testing.T) {
+	rng := ethtest.NewPseudoRand(2718)
+	caller := rng.Address()
+	subnet := rng.Hash()
+
+	var gotArgs *libevm.ContractAddressArgs
+	hooks := &hookstest.Stub{
+		ContractAddressFn: func(args *libevm.ContractAddressArgs) common.Address {
+			gotArgs = args
+			return common.BytesToAddress(crypto.Keccak256(subnet[:], crypto.CreateAddress(args.Caller, args.Nonce).Bytes()))
+		},
+	}
+	hooks.Register(t)
+
+	const nonce = 3
+	code := []byte{byte(vm.STOP)}
+	salt := rng.Hash()
+	namespaced := common.BytesToAddress(crypto.Keccak256(subnet[:], crypto.CreateAddress(caller, nonce).Bytes()))
+
+	tests := []struct {
+		name     string
+		create   func(*vm.EVM) ([]byte, common.Address, uint64, error)
+		wantArgs *libevm.ContractAddressArgs
+	}{
+		{
+			name: "Create",
+			create: func(evm *vm.EVM) ([]byte, common.Address, uint64, error) {
+				return evm.Create(vm.AccountRef(caller), code, 1e6, uint256.NewInt(0))
+			},
+			wantArgs: &libevm.ContractAddressArgs{
+				Caller: caller,
+				Nonce:  nonce,
+			},
+		},
+		{
+			name: "Create2",
+			create: func(evm *vm.EVM) ([]byte, common.Address, uint64, error) {
+				return evm.Create2(vm.AccountRef(caller), code, 1e6, uint256.NewInt(0), new(uint256.Int).SetBytes(salt[:]))
+			},
+			wantArgs: &libevm.ContractAddressArgs{
+				Caller:       caller,
+				Nonce:        nonce,
+				Salt:         &salt,
+				InitCodeHash: crypto.Keccak256Hash(code),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, evm := ethtest.NewZeroEVM(t)
+			state.SetNonce(caller, nonce)
+
+			_, addr, _, err := tt.create(evm)
+			require.NoError(t, err)
+			assert.Equal(t, namespaced, addr, "contract address")
+			assert.Equal(t, tt.wantArgs, gotArgs, "arguments to ContractAddressOverride hook")
+			assert.True(t, state.Exist(addr), "contract account exists at overridden address")
+		})
+	}
+}
+
 func TestActivePrecompilesOverride(t *testing.T) {
 	newRules := func() params.Rules {
 		return new(params.ChainConfig).Rules(big.NewInt(0), false, 0)
@@ -681,6 +817,456 @@ func TestPrecompileMakeCall(t *testing.T) {
 	}
 }
 
+func TestPrecompileOutboundCallTypes(t *testing.T) {
+	// `eoa` calls `sut`, the precompile under test, which makes an outbound
+	// call of the type specified by the first byte of its input. The outbound
+	// call is either to `dest`, which reflects env data, or to `store`, which
+	// is bytecode that performs an SSTORE.
+	eoa := common.HexToAddress("E0A")
+	sut := common.HexToAddress("7E57ED")
+	dest := common.HexToAddress("DE57")
+	store := common.HexToAddress("5702E")
+
+	const (
+		toDest byte = iota
+		toStore
+	)
+	call := func(env vm.PrecompileEnvironment, typ vm.CallType, addr common.Address, gas uint64) ([]byte, uint64, error) {
+		switch typ {
+		case vm.Call:
+			return env.Call(addr, nil, gas, uint256.NewInt(0))
+		case vm.CallCode:
+			return env.CallCode(addr, nil, gas, uint256.NewInt(0))
+		case vm.DelegateCall:
+			return env.DelegateCall(addr, nil, gas)
+		case vm.StaticCall:
+			return env.StaticCall(addr, nil, gas)
+		}
+		return nil, 0, fmt.Errorf("unsupported %T(%d)", typ, typ)
+	}
+
+	hooks := &hookstest.Stub{
+		PrecompileOverrides: map[common.Address]libevm.PrecompiledContract{
+			sut: vm.NewStatefulPrecompile(func(env vm.PrecompileEnvironment, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				addr := dest
+				if input[1] == toStore {
+					addr = store
+				}
+				return call(env, vm.CallType(input[0]), addr, suppliedGas)
+			}),
+			dest: vm.NewStatefulPrecompile(func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				out := &statefulPrecompileOutput{
+					Addresses:        env.Addresses(),
+					ReadOnly:         env.ReadOnly(),
+					IncomingCallType: env.IncomingCallType(),
+				}
+				return out.Bytes(), suppliedGas, nil
+			}),
+		},
+	}
+	hookstest.Register(t, params.Extras[*hookstest.Stub, *hookstest.Stub]{
+		NewRules: func(_ *params.ChainConfig, r *params.Rules, _ *hookstest.Stub, blockNum *big.Int, isMerge bool, timestamp uint64) *hookstest.Stub {
+			r.IsCancun = true // enable PUSH0
+			return hooks
+		},
+	})
+
+	var (
+		slot  common.Hash
+		value = common.Hash{31: 1}
+	)
+	storeCode := convertBytes[vm.OpCode, byte]([]vm.OpCode{
+		vm.PUSH1, 1,
+		vm.PUSH0,
+		vm.SSTORE,
+		vm.STOP,
+	})
+
+	tests := []struct {
+		typ vm.CallType
+		// Unlike TestPrecompileMakeCall, which tests outbound Call()s with
+		// different incoming call types, these test the AddressContext of a
+		// contract called by the precompile with different outbound types.
+		want statefulPrecompileOutput
+		// The address at which `store` is expected to have written, or nil if
+		// writing is expected to fail.
+		wantStoredAt *common.Address
+		wantStoreErr error
+	}{
+		{
+			typ: vm.Call,
+			want: statefulPrecompileOutput{
+				Addresses: &libevm.AddressContext{
+					Origin: eoa,
+					Caller: sut,
+					Self:   dest,
+				},
+				IncomingCallType: vm.Call,
+			},
+			wantStoredAt: &store,
+		},
+		{
+			typ: vm.CallCode,
+			want: statefulPrecompileOutput{
+				Addresses: &libevm.AddressContext{
+					Origin: eoa,
+					Caller: sut,
+					Self:   sut, // as with the CALLCODE op code
+				},
+				IncomingCallType: vm.CallCode,
+			},
+			wantStoredAt: &sut,
+		},
+		{
+			typ: vm.DelegateCall,
+			want: statefulPrecompileOutput{
+				Addresses: &libevm.AddressContext{
+					Origin: eoa,
+					Caller: eoa, // inherited from `sut`
+					Self:   sut,
+				},
+				IncomingCallType: vm.DelegateCall,
+			},
+			wantStoredAt: &sut,
+		},
+		{
+			typ: vm.StaticCall,
+			want: statefulPrecompileOutput{
+				Addresses: &libevm.AddressContext{
+					Origin: eoa,
+					Caller: sut,
+					Self:   dest,
+				},
+				IncomingCallType: vm.StaticCall,
+				ReadOnly:         true,
+			},
+			wantStoreErr: vm.ErrWriteProtection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ.String(), func(t *testing.T) {
+			state, evm := ethtest.NewZeroEVM(t)
+			evm.Origin = eoa
+			state.CreateAccount(store)
+			state.SetCode(store, storeCode)
+			// Normally performed by [core.StateTransition] but necessary for
+			// the SSTORE gas calculation.
+			state.AddAddressToAccessList(sut)
+			state.AddAddressToAccessList(store)
+
+			t.Run("reflect", func(t *testing.T) {
+				got, _, err := evm.Call(vm.AccountRef(eoa), sut, []byte{byte(tt.typ), toDest}, 1e6, uint256.NewInt(0))
+				require.NoError(t, err)
+				require.Equal(t, tt.want.String(), string(got))
+			})
+
+			t.Run("SSTORE", func(t *testing.T) {
+				_, _, err := evm.Call(vm.AccountRef(eoa), sut, []byte{byte(tt.typ), toStore}, 1e6, uint256.NewInt(0))
+				require.ErrorIs(t, err, tt.wantStoreErr)
+
+				for _, addr := range []common.Address{sut, store} {
+					want := common.Hash{}
+					if tt.wantStoredAt != nil && *tt.wantStoredAt == addr {
+						want = value
+					}
+					assert.Equalf(t, want, state.GetState(addr, slot), "GetState(%v, %v)", addr, slot)
+				}
+			})
+		})
+	}
+
+	t.Run("unsafe_caller_proxy", func(t *testing.T) {
+		_, evm := ethtest.NewZeroEVM(t)
+		evm.Origin = eoa
+		precompile := vm.NewStatefulPrecompile(func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+			return env.DelegateCall(dest, nil, suppliedGas, vm.WithUNSAFECallerAddressProxying())
+		})
+		orig := hooks.PrecompileOverrides[sut]
+		hooks.PrecompileOverrides[sut] = precompile
+		t.Cleanup(func() { hooks.PrecompileOverrides[sut] = orig })
+		_, _, err := evm.Call(vm.AccountRef(eoa), sut, nil, 1e6, uint256.NewInt(0))
+		require.Error(t, err, "DelegateCall() with WithUNSAFECallerAddressProxying()")
+	})
+}
+
+func TestPrecompileCreate(t *testing.T) {
+	sut := common.HexToAddress("7E57ED")
+	eoa := common.HexToAddress("E0A")
+	rng := ethtest.NewPseudoRand(271828)
+	salt := rng.Hash()
+
+	const (
+		create byte = iota
+		create2
+	)
+	hooks := &hookstest.Stub{
+		PrecompileOverrides: map[common.Address]libevm.PrecompiledContract{
+			// The first byte of the input determines the type of creation and
+			// the remaining bytes are the init code.
+			sut: vm.NewStatefulPrecompile(func(env vm.PrecompileEnvironment, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				var (
+					addr         common.Address
+					gasRemaining uint64
+					err          error
+				)
+				switch input[0] {
+				case create:
+					_, addr, gasRemaining, err = env.Create(input[1:], suppliedGas, uint256.NewInt(0))
+				case create2:
+					_, addr, gasRemaining, err = env.Create2(input[1:], suppliedGas, uint256.NewInt(0), new(uint256.Int).SetBytes(salt[:]))
+				}
+				return addr.Bytes(), gasRemaining, err
+			}),
+		},
+	}
+	hookstest.Register(t, params.Extras[*hookstest.Stub, *hookstest.Stub]{
+		NewRules: func(_ *params.ChainConfig, r *params.Rules, _ *hookstest.Stub, blockNum *big.Int, isMerge bool, timestamp uint64) *hookstest.Stub {
+			r.IsShanghai = true // enable EIP-3860
+			r.IsCancun = true   // enable PUSH0
+			return hooks
+		},
+	})
+
+	const deployed byte = 0xAB
+	initCode := convertBytes[vm.OpCode, byte]([]vm.OpCode{
+		vm.PUSH1, vm.OpCode(deployed),
+		vm.PUSH0,
+		vm.MSTORE8,
+		vm.PUSH1, 1,
+		vm.PUSH0,
+		vm.RETURN,
+	})
+	errCannotCreate := errors.New("cannot create")
+
+	tests := []struct {
+		name          string
+		typ           byte
+		initCode      []byte
+		staticCall    bool
+		canCreateErr  error
+		wantAddr      common.Address
+		wantErr       error
+		wantSUTNonce  uint64
+		wantCodeAtNew []byte
+	}{
+		{
+			name:          "Create",
+			typ:           create,
+			initCode:      initCode,
+			wantAddr:      crypto.CreateAddress(sut, 0),
+			wantSUTNonce:  1,
+			wantCodeAtNew: []byte{deployed},
+		},
+		{
+			name:          "Create2",
+			typ:           create2,
+			initCode:      initCode,
+			wantAddr:      crypto.CreateAddress2(sut, salt, crypto.Keccak256(initCode)),
+			wantSUTNonce:  1,
+			wantCodeAtNew: []byte{deployed},
+		},
+		{
+			name:         "CanCreateContract_error",
+			typ:          create,
+			initCode:     initCode,
+			canCreateErr: errCannotCreate,
+			wantErr:      errCannotCreate,
+			// Although incremented before the hook is called, the nonce is
+			// reverted because the precompile returns the error.
+			wantSUTNonce: 0,
+		},
+		{
+			name:       "read_only",
+			typ:        create,
+			initCode:   initCode,
+			staticCall: true,
+			wantErr:    vm.ErrWriteProtection,
+		},
+		{
+			name:     "init_code_too_large",
+			typ:      create2,
+			initCode: make([]byte, params.MaxInitCodeSize+1),
+			wantErr:  vm.ErrMaxInitCodeSizeExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks.CanCreateContractFn = func(cc *libevm.AddressContext, gas uint64, _ libevm.StateReader) (uint64, error) {
+				assert.Equal(t, sut, cc.Caller, "CanCreateContract() caller")
+				return gas, tt.canCreateErr
+			}
+			t.Cleanup(func() { hooks.CanCreateContractFn = nil })
+
+			state, evm := ethtest.NewZeroEVM(t)
+			evm.Origin = eoa
+
+			const gasLimit = 1e6
+			input := append([]byte{tt.typ}, tt.initCode...)
+			call := evm.Call
+			if tt.staticCall {
+				call = func(caller vm.ContractRef, addr common.Address, input []byte, gas uint64, _ *uint256.Int) ([]byte, uint64, error) {
+					return evm.StaticCall(caller, addr, input, gas)
+				}
+			}
+			got, gasRemaining, err := call(vm.AccountRef(eoa), sut, input, gasLimit, uint256.NewInt(0))
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantSUTNonce, state.GetNonce(sut), "nonce of precompile")
+			if tt.wantErr != nil {
+				return
+			}
+
+			assert.Equal(t, tt.wantAddr, common.BytesToAddress(got), "address of new contract")
+			assert.Equal(t, tt.wantCodeAtNew, state.GetCode(tt.wantAddr), "code of new contract")
+			assert.Less(t, gasRemaining, uint64(gasLimit), "gas remaining")
+		})
+	}
+}
+
+func TestPrecompileGasAccounting(t *testing.T) {
+	sut := common.HexToAddress("7E57ED")
+	eoa := common.HexToAddress("E0A")
+	rng := ethtest.NewPseudoRand(161803)
+	addr := rng.Address()
+	slot := rng.Hash()
+
+	const (
+		gasLimit = 1e6
+		charge   = 1234
+		refund   = 42
+	)
+	errWrapped := fmt.Errorf("wrapped: %w", vm.ErrOutOfGas)
+
+	tests := []struct {
+		name             string
+		berlin           bool
+		static           bool
+		run              vm.PrecompiledStatefulContract
+		wantErr          error
+		wantGasRemaining uint64
+		wantRefund       uint64
+	}{
+		{
+			name: "UseGas_then_return_supplied",
+			run: func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				if err := env.UseGas(charge); err != nil {
+					return nil, 0, err
+				}
+				return nil, suppliedGas, nil // capped at env.Gas()
+			},
+			wantGasRemaining: gasLimit - charge,
+		},
+		{
+			name: "UseGas_then_return_less",
+			run: func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				if err := env.UseGas(charge); err != nil {
+					return nil, 0, err
+				}
+				return nil, env.Gas() - 1, nil
+			},
+			wantGasRemaining: gasLimit - charge - 1,
+		},
+		{
+			name: "UseGas_insufficient",
+			run: func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				err := env.UseGas(suppliedGas + 1)
+				if env.Gas() != 0 {
+					return nil, suppliedGas, fmt.Errorf("Gas() after UseGas(<too much>) = %d; want 0", env.Gas())
+				}
+				return nil, suppliedGas, err
+			},
+			wantErr:          vm.ErrOutOfGas,
+			wantGasRemaining: 0,
+		},
+		{
+			name: "wrapped_ErrOutOfGas",
+			run: func(_ vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				return nil, suppliedGas, errWrapped
+			},
+			wantErr:          errWrapped,
+			wantGasRemaining: 0,
+		},
+		{
+			name:   "state_access_cold_then_warm",
+			berlin: true,
+			run: func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				for i := 0; i < 2; i++ {
+					if err := env.UseAccountAccessGas(addr); err != nil {
+						return nil, 0, err
+					}
+					if err := env.UseStorageAccessGas(addr, slot); err != nil {
+						return nil, 0, err
+					}
+				}
+				return nil, env.Gas(), nil
+			},
+			wantGasRemaining: gasLimit -
+				params.ColdAccountAccessCostEIP2929 - params.ColdSloadCostEIP2929 -
+				2*params.WarmStorageReadCostEIP2929,
+		},
+		{
+			name: "state_access_before_berlin",
+			run: func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				if err := env.UseAccountAccessGas(addr); err != nil {
+					return nil, 0, err
+				}
+				if err := env.UseStorageAccessGas(addr, slot); err != nil {
+					return nil, 0, err
+				}
+				return nil, env.Gas(), nil
+			},
+			wantGasRemaining: gasLimit,
+		},
+		{
+			name: "AddRefund",
+			run: func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				return nil, suppliedGas, env.AddRefund(refund)
+			},
+			wantGasRemaining: gasLimit,
+			wantRefund:       refund,
+		},
+		{
+			name:   "AddRefund_read_only",
+			static: true,
+			run: func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				return nil, suppliedGas, env.AddRefund(refund)
+			},
+			wantErr: vm.ErrWriteProtection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := &hookstest.Stub{
+				PrecompileOverrides: map[common.Address]libevm.PrecompiledContract{
+					sut: vm.NewStatefulPrecompile(tt.run),
+				},
+			}
+			hookstest.Register(t, params.Extras[*hookstest.Stub, *hookstest.Stub]{
+				NewRules: func(_ *params.ChainConfig, r *params.Rules, _ *hookstest.Stub, blockNum *big.Int, isMerge bool, timestamp uint64) *hookstest.Stub {
+					r.IsBerlin = tt.berlin
+					return hooks
+				},
+			})
+
+			state, evm := ethtest.NewZeroEVM(t)
+			var (
+				gasRemaining uint64
+				err          error
+			)
+			if tt.static {
+				_, gasRemaining, err = evm.StaticCall(vm.AccountRef(eoa), sut, nil, gasLimit)
+			} else {
+				_, gasRemaining, err = evm.Call(vm.AccountRef(eoa), sut, nil, gasLimit, uint256.NewInt(0))
+			}
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantGasRemaining, gasRemaining, "gas remaining")
+			assert.Equal(t, tt.wantRefund, state.GetRefund(), "refund")
+		})
+	}
+}
+
 //nolint:testableexamples // Including output would only make the example more complicated and hide the true intent
 func ExamplePrecompileEnvironment() {
 	// To determine the actual caller of a precompile, as against the effective
@@ -703,3 +1289,318 @@ func ExamplePrecompileEnvironment() {
 	// variable to include it in this example function.
 	_ = actualCaller
 }
+
+func TestAccountExtrasViaInterfaces(t *testing.T) {
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	payloads := types.RegisterExtras[
+		types.NOOPHeaderHooks, *types.NOOPHeaderHooks,
+		types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks,
+		uint64,
+	]()
+
+	rng := ethtest.NewPseudoRand(141421)
+	precompile := rng.Address()
+	caller := rng.Address()
+	errRevert := errors.New("revert after incrementing")
+
+	hooks := &hookstest.Stub{
+		PrecompileOverrides: map[common.Address]libevm.PrecompiledContract{
+			// Increments the caller's extra payload, and returns the old value.
+			precompile: vm.NewStatefulPrecompile(func(env vm.PrecompileEnvironment, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				who := env.Addresses().Caller
+				old := state.GetExtra(env.ReadOnlyState(), payloads, who)
+				state.SetExtra(env.StateDB(), payloads, who, old+1)
+				if len(input) > 0 {
+					return nil, 0, errRevert
+				}
+				return uint256.NewInt(old).PaddedBytes(32), suppliedGas, nil
+			}),
+		},
+		CanExecuteTransactionFn: func(from common.Address, _ *common.Address, s libevm.StateReader) error {
+			if state.GetExtra(s, payloads, from) > 1 {
+				return errors.New("blocked by account extra")
+			}
+			return nil
+		},
+	}
+	hooks.Register(t)
+
+	sdb, evm := ethtest.NewZeroEVM(t)
+	sdb.SetNonce(caller, 1) // avoid EIP-158 deletion of an otherwise empty account
+
+	call := func(t *testing.T, input []byte) ([]byte, error) {
+		t.Helper()
+		ret, _, err := evm.Call(vm.AccountRef(caller), precompile, input, 1e6, new(uint256.Int))
+		return ret, err
+	}
+
+	t.Run("increment", func(t *testing.T) {
+		for want := uint64(0); want < 2; want++ {
+			got, err := call(t, nil)
+			require.NoError(t, err)
+			assert.Equal(t, uint256.NewInt(want).PaddedBytes(32), got, "old value returned by precompile")
+		}
+		assert.Equal(t, uint64(2), state.GetExtra(sdb, payloads, caller), "state.GetExtra() after increments")
+	})
+
+	t.Run("revert", func(t *testing.T) {
+		_, err := call(t, []byte{1})
+		require.ErrorIs(t, err, errRevert)
+		assert.Equal(t, uint64(2), state.GetExtra(sdb, payloads, caller), "state.GetExtra() after reverted increment")
+	})
+
+	t.Run("CanExecuteTransaction", func(t *testing.T) {
+		msg := &core.Message{
+			From:     caller,
+			To:       &precompile,
+			Value:    new(big.Int),
+			GasLimit: 1e6,
+		}
+		_, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(30e6))
+		require.EqualError(t, err, "blocked by account extra")
+	})
+}
+
+// ledger is a [libevm.Transferrer] that records balances in the storage of a
+// single account, keyed by the address of the balance holder.
+type ledger common.Address
+
+func (l ledger) Balance(s libevm.StateReader, addr common.Address) *uint256.Int {
+	v := s.GetState(common.Address(l), common.BytesToHash(addr.Bytes()))
+	return new(uint256.Int).SetBytes(v[:])
+}
+
+func (l ledger) set(s libevm.StateDB, addr common.Address, bal *uint256.Int) {
+	s.SetState(common.Address(l), common.BytesToHash(addr.Bytes()), bal.Bytes32())
+}
+
+func (l ledger) CanTransfer(s libevm.StateReader, from common.Address, amount *uint256.Int) bool {
+	return l.Balance(s, from).Cmp(amount) >= 0
+}
+
+func (l ledger) Debit(s libevm.StateDB, from common.Address, amount *uint256.Int) {
+	l.set(s, from, new(uint256.Int).Sub(l.Balance(s, from), amount))
+}
+
+func (l ledger) Credit(s libevm.StateDB, to common.Address, amount *uint256.Int) {
+	l.set(s, to, new(uint256.Int).Add(l.Balance(s, to), amount))
+}
+
+func TestPrecompileCallWithAsset(t *testing.T) {
+	rng := ethtest.NewPseudoRand(1729)
+	eoa := rng.Address()
+	sut := rng.Address()
+	dest := rng.Address()
+
+	asset := libevm.AssetID(rng.Hash())
+	unsupported := libevm.AssetID(rng.Hash())
+	assets := ledger(rng.Address())
+
+	const startBalance = 1000
+	type callArgs struct {
+		asset libevm.AssetID
+		value uint64
+		typ   vm.CallType
+	}
+	var args callArgs
+
+	hooks := &hookstest.Stub{
+		TransferOverrides: map[libevm.AssetID]libevm.Transferrer{
+			asset: assets,
+		},
+		PrecompileOverrides: map[common.Address]libevm.PrecompiledContract{
+			sut: vm.NewStatefulPrecompile(func(env vm.PrecompileEnvironment, _ []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				val := uint256.NewInt(args.value)
+				opt := vm.WithAsset(args.asset)
+				switch args.typ {
+				case vm.Call:
+					return env.Call(dest, nil, suppliedGas, val, opt)
+				case vm.CallCode:
+					return env.CallCode(dest, nil, suppliedGas, val, opt)
+				default:
+					return nil, 0, fmt.Errorf("unsupported %v", args.typ)
+				}
+			}),
+		},
+	}
+	hooks.Register(t)
+
+	tests := []struct {
+		name                  string
+		args                  callArgs
+		wantErr               error
+		wantSUT, wantDest     uint64 // ledger balances
+		wantNativeTransferred bool
+	}{
+		{
+			name:     "overridden_asset",
+			args:     callArgs{asset: asset, value: 42, typ: vm.Call},
+			wantSUT:  startBalance - 42,
+			wantDest: 42,
+		},
+		{
+			name:    "overridden_asset_insufficient_balance",
+			args:    callArgs{asset: asset, value: startBalance + 1, typ: vm.Call},
+			wantErr: vm.ErrInsufficientBalance,
+			wantSUT: startBalance,
+		},
+		{
+			name:    "unsupported_asset",
+			args:    callArgs{asset: unsupported, value: 1, typ: vm.Call},
+			wantErr: vm.ErrInsufficientBalance,
+			wantSUT: startBalance,
+		},
+		{
+			name:    "unsupported_asset_zero_value",
+			args:    callArgs{asset: unsupported, typ: vm.Call},
+			wantSUT: startBalance,
+		},
+		{
+			name:                  "native_asset",
+			args:                  callArgs{asset: libevm.NativeAsset, value: 7, typ: vm.Call},
+			wantSUT:               startBalance,
+			wantNativeTransferred: true,
+		},
+		{
+			name:    "call_code",
+			args:    callArgs{asset: asset, typ: vm.CallCode},
+			wantErr: errors.New("only supported for Call"),
+			wantSUT: startBalance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args = tt.args
+			state, evm := ethtest.NewZeroEVM(t)
+			state.SetNonce(common.Address(assets), 1)
+			assets.set(state, sut, uint256.NewInt(startBalance))
+			state.SetBalance(sut, uint256.NewInt(startBalance))
+
+			_, _, err := evm.Call(vm.AccountRef(eoa), sut, nil, 1e6, uint256.NewInt(0))
+			switch want := tt.wantErr; {
+			case want == nil:
+				require.NoError(t, err, "evm.Call([precompile making outbound call])")
+			case errors.Is(err, want):
+			default:
+				require.ErrorContains(t, err, want.Error(), "evm.Call([precompile making outbound call])")
+			}
+
+			assert.Equal(t, uint256.NewInt(tt.wantSUT), assets.Balance(state, sut), "ledger balance of caller")
+			assert.Equal(t, uint256.NewInt(tt.wantDest), assets.Balance(state, dest), "ledger balance of recipient")
+
+			wantNative := uint256.NewInt(0)
+			if tt.wantNativeTransferred {
+				wantNative.SetUint64(tt.args.value)
+			}
+			assert.Equal(t, wantNative, state.GetBalance(dest), "native balance of recipient")
+		})
+	}
+}
+
+func TestSelfDestructWithNativeTransferOverride(t *testing.T) {
+	rng := ethtest.NewPseudoRand(314159)
+	eoa := rng.Address()
+	contract := rng.Address()
+	beneficiary := rng.Address()
+	assets := ledger(rng.Address())
+
+	hooks := &hookstest.Stub{
+		TransferOverrides: map[libevm.AssetID]libevm.Transferrer{
+			libevm.NativeAsset: assets,
+		},
+	}
+	hooks.Register(t)
+
+	code := append([]byte{byte(vm.PUSH20)}, beneficiary.Bytes()...)
+	code = append(code, byte(vm.SELFDESTRUCT))
+
+	tests := []struct {
+		name string
+		opts []ethtest.EVMOption
+	}{
+		{
+			name: "pre_cancun",
+		},
+		{
+			name: "eip6780",
+			opts: []ethtest.EVMOption{
+				ethtest.WithChainConfig(params.MergedTestChainConfig),
+				ethtest.WithBlockContext(vm.BlockContext{
+					CanTransfer: core.CanTransfer,
+					Transfer:    core.Transfer,
+					BlockNumber: big.NewInt(0),
+					Random:      &common.Hash{},
+				}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const (
+				ledgerBalance = 1000
+				nativeBalance = 42
+			)
+			state, evm := ethtest.NewZeroEVM(t, tt.opts...)
+			state.SetNonce(common.Address(assets), 1)
+			state.SetCode(contract, code)
+			assets.set(state, contract, uint256.NewInt(ledgerBalance))
+			state.SetBalance(contract, uint256.NewInt(nativeBalance))
+
+			_, _, err := evm.Call(vm.AccountRef(eoa), contract, nil, 1e6, uint256.NewInt(0))
+			require.NoError(t, err, "evm.Call([SELFDESTRUCT])")
+
+			assert.Zero(t, assets.Balance(state, contract).Uint64(), "ledger balance of self-destructed contract")
+			assert.Equal(t, uint256.NewInt(ledgerBalance), assets.Balance(state, beneficiary), "ledger balance of beneficiary")
+			assert.Zero(t, state.GetBalance(beneficiary).Uint64(), "native balance of beneficiary")
+		})
+	}
+}
+
+func TestPrecompileExecutionContext(t *testing.T) {
+	type ctxKey struct{}
+	rng := ethtest.NewPseudoRand(2718)
+	precompile := rng.Address()
+	wantValue := rng.Hash()
+
+	var gotValue any
+	hooks := &hookstest.Stub{
+		PrecompileOverrides: map[common.Address]libevm.PrecompiledContract{
+			precompile: vm.NewStatefulPrecompile(func(env vm.PrecompileEnvironment, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+				ctx := env.Context()
+				gotValue = ctx.Value(ctxKey{})
+				<-ctx.Done()
+				return nil, 0, context.Cause(ctx)
+			}),
+		},
+	}
+	hooks.Register(t)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, wantValue))
+	_, evm := ethtest.NewZeroEVM(t)
+	evm.SetExecutionContext(ctx)
+	cancel()
+
+	_, _, err := evm.Call(vm.AccountRef(rng.Address()), precompile, nil, 1e6, uint256.NewInt(0))
+	require.ErrorIs(t, err, context.Canceled, "%T.Call([precompile observing %T.Context()])", evm, vm.PrecompileEnvironment(nil))
+	assert.Equal(t, wantValue, gotValue, "value carried by execution context")
+	assert.ErrorIs(t, evm.AbortCause(), vm.ErrExecutionAborted, "%T.AbortCause()", evm)
+}
+
+func TestExplicitCancelWithoutExecutionContext(t *testing.T) {
+	rng := ethtest.NewPseudoRand(1414)
+	contract := rng.Address()
+
+	state, evm := ethtest.NewZeroEVM(t)
+	// JUMPDEST; PUSH1 0; JUMP; i.e. an infinite loop.
+	state.SetCode(contract, []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0, byte(vm.JUMP)})
+
+	assert.Equal(t, context.Background(), evm.ExecutionContext(), "%T.ExecutionContext() when not set", evm)
+	evm.Cancel()
+	// An explicit cancellation halts as if by the STOP op code, as in geth.
+	_, _, err := evm.Call(vm.AccountRef(rng.Address()), contract, nil, 1e6, uint256.NewInt(0))
+	require.NoError(t, err, "%T.Call() after %T.Cancel()", evm, evm)
+	assert.NoError(t, evm.AbortCause(), "%T.AbortCause()", evm)
+}
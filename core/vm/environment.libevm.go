@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 
@@ -42,6 +43,14 @@ func (e *environment) ReadOnlyState() libevm.StateReader { return e.evm.StateDB
 func (e *environment) IncomingCallType() CallType        { return e.callType }
 func (e *environment) BlockNumber() *big.Int             { return new(big.Int).Set(e.evm.Context.BlockNumber) }
 func (e *environment) BlockTime() uint64                 { return e.evm.Context.Time }
+func (e *environment) Context() context.Context          { return e.evm.ExecutionContext() }
+
+func (e *environment) Value() *uint256.Int {
+	if v := e.self.Value(); v != nil {
+		return new(uint256.Int).Set(v)
+	}
+	return new(uint256.Int)
+}
 
 func (e *environment) ReadOnly() bool {
 	// A switch statement provides clearer code coverage for difficult-to-test
@@ -59,6 +68,49 @@ func (e *environment) ReadOnly() bool {
 	}
 }
 
+func (e *environment) Gas() uint64 { return e.self.Gas }
+
+func (e *environment) UseGas(gas uint64) error {
+	if !e.self.UseGas(gas) {
+		e.self.Gas = 0
+		return ErrOutOfGas
+	}
+	return nil
+}
+
+func (e *environment) UseAccountAccessGas(addr common.Address) error {
+	if !e.evm.chainRules.IsBerlin {
+		return nil
+	}
+	// As with the op codes, access lists are modified even if read-only.
+	db := e.evm.StateDB
+	if db.AddressInAccessList(addr) {
+		return e.UseGas(params.WarmStorageReadCostEIP2929)
+	}
+	db.AddAddressToAccessList(addr)
+	return e.UseGas(params.ColdAccountAccessCostEIP2929)
+}
+
+func (e *environment) UseStorageAccessGas(addr common.Address, slot common.Hash) error {
+	if !e.evm.chainRules.IsBerlin {
+		return nil
+	}
+	db := e.evm.StateDB
+	if _, ok := db.SlotInAccessList(addr, slot); ok {
+		return e.UseGas(params.WarmStorageReadCostEIP2929)
+	}
+	db.AddSlotToAccessList(addr, slot)
+	return e.UseGas(params.ColdSloadCostEIP2929)
+}
+
+func (e *environment) AddRefund(gas uint64) error {
+	if e.ReadOnly() {
+		return ErrWriteProtection
+	}
+	e.evm.StateDB.AddRefund(gas)
+	return nil
+}
+
 func (e *environment) Addresses() *libevm.AddressContext {
 	return &libevm.AddressContext{
 		Origin: e.evm.Origin,
@@ -90,6 +142,50 @@ func (e *environment) Call(addr common.Address, input []byte, gas uint64, value
 	return e.callContract(Call, addr, input, gas, value, opts...)
 }
 
+func (e *environment) CallCode(addr common.Address, input []byte, gas uint64, value *uint256.Int, opts ...CallOption) ([]byte, uint64, error) {
+	return e.callContract(CallCode, addr, input, gas, value, opts...)
+}
+
+func (e *environment) DelegateCall(addr common.Address, input []byte, gas uint64, opts ...CallOption) ([]byte, uint64, error) {
+	return e.callContract(DelegateCall, addr, input, gas, nil, opts...)
+}
+
+func (e *environment) StaticCall(addr common.Address, input []byte, gas uint64, opts ...CallOption) ([]byte, uint64, error) {
+	return e.callContract(StaticCall, addr, input, gas, nil, opts...)
+}
+
+func (e *environment) Create(code []byte, gas uint64, value *uint256.Int) ([]byte, common.Address, uint64, error) {
+	return e.createContract(CREATE, code, gas, value, nil)
+}
+
+func (e *environment) Create2(code []byte, gas uint64, value *uint256.Int, salt *uint256.Int) ([]byte, common.Address, uint64, error) {
+	return e.createContract(CREATE2, code, gas, value, salt)
+}
+
+func (e *environment) createContract(typ OpCode, code []byte, gas uint64, value, salt *uint256.Int) ([]byte, common.Address, uint64, error) {
+	// The remaining checks are performed by [EVM.create], but the following
+	// are usually either handled by [EVMInterpreter.Run] or by the respective
+	// op codes' gas functions, neither of which are used by precompiles.
+	if e.ReadOnly() {
+		return nil, common.Address{}, gas, ErrWriteProtection
+	}
+	if e.evm.chainRules.IsShanghai && len(code) > params.MaxInitCodeSize {
+		return nil, common.Address{}, gas, fmt.Errorf("%w: code size %v limit %v", ErrMaxInitCodeSizeExceeded, len(code), params.MaxInitCodeSize)
+	}
+
+	e.evm.depth++
+	defer func() { e.evm.depth-- }()
+
+	switch typ {
+	case CREATE:
+		return e.evm.Create(e.self, code, gas, value)
+	case CREATE2:
+		return e.evm.Create2(e.self, code, gas, value, salt)
+	default:
+		return nil, common.Address{}, gas, fmt.Errorf("unimplemented precompile create type %v", typ)
+	}
+}
+
 func (e *environment) callContract(typ CallType, addr common.Address, input []byte, gas uint64, value *uint256.Int, opts ...CallOption) ([]byte, uint64, error) {
 	// Depth and read-only setting are handled by [EVMInterpreter.Run], which
 	// isn't used for precompiles, so we need to do it ourselves to maintain the
@@ -104,10 +200,18 @@ func (e *environment) callContract(typ CallType, addr common.Address, input []by
 		defer func() { in.readOnly = false }()
 	}
 
-	var caller ContractRef = e.self
+	var (
+		caller ContractRef = e.self
+		asset              = libevm.NativeAsset
+	)
 	for _, o := range opts {
 		switch o := o.(type) {
 		case callOptUNSAFECallerAddressProxy:
+			if typ != Call {
+				// The option exists purely for backwards compatibility, which
+				// only requires support for Call.
+				return nil, gas, fmt.Errorf("%T only supported for %v", o, Call)
+			}
 			// Note that, in addition to being unsafe, this breaks an EVM
 			// assumption that the caller ContractRef is always a *Contract.
 			caller = AccountRef(e.self.CallerAddress)
@@ -116,6 +220,12 @@ func (e *environment) callContract(typ CallType, addr common.Address, input []by
 				// CallerAddress was inherited.
 				caller = AccountRef(e.self.Address())
 			}
+		case callOptAsset:
+			if typ != Call {
+				// Only Call transfers value to another account.
+				return nil, gas, fmt.Errorf("%T only supported for %v", o, Call)
+			}
+			asset = libevm.AssetID(o)
 		case nil:
 		default:
 			return nil, gas, fmt.Errorf("unsupported option %T", o)
@@ -127,14 +237,16 @@ func (e *environment) callContract(typ CallType, addr common.Address, input []by
 		if in.readOnly && !value.IsZero() {
 			return nil, gas, ErrWriteProtection
 		}
+		e.evm.callAsset = asset
 		return e.evm.Call(caller, addr, input, gas, value)
-	case CallCode, DelegateCall, StaticCall:
-		// TODO(arr4n): these cases should be very similar to CALL, hence the
-		// early abstraction, to signal to future maintainers. If implementing
-		// them, there's likely no need to honour the
-		// [callOptUNSAFECallerAddressProxy] because it's purely for backwards
-		// compatibility.
-		fallthrough
+	case CallCode:
+		// As with the CALLCODE op code, there is no write protection because
+		// the value is transferred to self.
+		return e.evm.CallCode(caller, addr, input, gas, value)
+	case DelegateCall:
+		return e.evm.DelegateCall(caller, addr, input, gas)
+	case StaticCall:
+		return e.evm.StaticCall(caller, addr, input, gas)
 	default:
 		return nil, gas, fmt.Errorf("unimplemented precompile call type %v", typ)
 	}
@@ -0,0 +1,172 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/holiman/uint256"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/libevm"
+)
+
+// canTransfer is the multi-asset equivalent of [BlockContext.CanTransfer],
+// which it uses for the native asset unless the transfer is overridden by the
+// [params.RulesHooks.TransferOverride] hook. Non-native assets that aren't
+// overridden can't be transferred.
+func (evm *EVM) canTransfer(from common.Address, amount *uint256.Int, asset libevm.AssetID) bool {
+	if t, ok := evm.chainRules.Hooks().TransferOverride(asset); ok {
+		return t.CanTransfer(evm.StateDB, from, amount)
+	}
+	if asset != libevm.NativeAsset {
+		return amount.IsZero()
+	}
+	return evm.Context.CanTransfer(evm.StateDB, from, amount)
+}
+
+// transfer is the multi-asset equivalent of [BlockContext.Transfer], with the
+// same semantics as [EVM.canTransfer], which MUST be called first.
+func (evm *EVM) transfer(from, to common.Address, amount *uint256.Int, asset libevm.AssetID) {
+	if t, ok := evm.chainRules.Hooks().TransferOverride(asset); ok {
+		t.Debit(evm.StateDB, from, amount)
+		t.Credit(evm.StateDB, to, amount)
+		return
+	}
+	if asset != libevm.NativeAsset {
+		return // canTransfer() guarantees a zero amount
+	}
+	evm.Context.Transfer(evm.StateDB, from, to, amount)
+}
+
+// transferNativeBalance is used by SELFDESTRUCT to move the entire balance of
+// the native asset from one account to another. If the native asset's transfer
+// is overridden by the [params.RulesHooks.TransferOverride] hook then the
+// balance is moved by the [libevm.Transferrer] and returned along with true.
+// Otherwise it is a no-op that returns false, and the caller MUST perform the
+// default behaviour.
+func (evm *EVM) transferNativeBalance(from, to common.Address) (*uint256.Int, bool) {
+	t, ok := evm.chainRules.Hooks().TransferOverride(libevm.NativeAsset)
+	if !ok {
+		return nil, false
+	}
+	balance := t.Balance(evm.StateDB, from)
+	t.Debit(evm.StateDB, from, balance)
+	t.Credit(evm.StateDB, to, balance)
+	return balance, true
+}
+
+// consumeCallAsset returns the asset to be transferred by the current call to
+// [EVM.Call], as set by a precompile with [WithAsset], resetting it to the
+// native asset so it isn't inherited by nested calls.
+func (evm *EVM) consumeCallAsset() libevm.AssetID {
+	a := evm.callAsset
+	evm.callAsset = libevm.NativeAsset
+	return a
+}
+
+// canDeployCode is a convenience wrapper for calling the
+// [params.RulesHooks.CanDeployCode] hook, updating the contract's remaining
+// gas.
+func (evm *EVM) canDeployCode(contract *Contract, caller ContractRef, address common.Address, code []byte) error {
+	addrs := &libevm.AddressContext{Origin: evm.Origin, Caller: caller.Address(), Self: address}
+	gas, err := evm.chainRules.Hooks().CanDeployCode(addrs, code, contract.Gas, evm.StateDB)
+	if gas > contract.Gas {
+		// The hook can only charge gas, never refund it. As with any other
+		// error, all remaining gas is consumed.
+		return fmt.Errorf("CanDeployCode hook returned %d gas remaining; more than the %d supplied", gas, contract.Gas)
+	}
+	contract.Gas = gas
+	return err
+}
+
+// ErrExecutionAborted is wrapped by the error returned by execution that was
+// aborted because the context set with [EVM.SetExecutionContext] is done. The
+// error also wraps the [context.Cause] of the context, so can be tested for
+// [context.Canceled] and [context.DeadlineExceeded].
+var ErrExecutionAborted = errors.New("execution aborted")
+
+// SetExecutionContext sets the context observed by execution, which is
+// returned by [EVM.ExecutionContext] and [PrecompileEnvironment.Context]. It
+// MUST be called before execution begins and a nil context clears any that was
+// previously set.
+//
+// Once ctx is done, the EVM is [EVM.Cancel]led. Unlike an explicit call to
+// Cancel, which halts execution as if by the STOP op code, the interpreter then
+// returns an error that [errors.Is] [ErrExecutionAborted]. As with Cancel,
+// halting isn't necessarily immediate and a cancelled EVM MUST NOT be reused.
+//
+// The context is observed until it is done or until SetExecutionContext is
+// called again, after which the EVM will no longer be cancelled by it. Callers
+// that wish to reuse the EVM after execution MUST therefore call
+// SetExecutionContext(nil) instead of relying on cancellation of ctx.
+func (evm *EVM) SetExecutionContext(ctx context.Context) {
+	if stop := evm.stopObservingCtx; stop != nil {
+		stop()
+		evm.stopObservingCtx = nil
+	}
+	evm.ctx = ctx
+	if ctx == nil || ctx.Done() == nil {
+		return // never done
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			evm.Cancel()
+		case <-stop:
+		}
+	}()
+	evm.stopObservingCtx = func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// ExecutionContext returns the context set with [EVM.SetExecutionContext], or
+// [context.Background] if none was set. Long-running hooks SHOULD observe its
+// cancellation.
+func (evm *EVM) ExecutionContext() context.Context {
+	if evm.ctx == nil {
+		return context.Background()
+	}
+	return evm.ctx
+}
+
+// AbortCause returns a non-nil error, which [errors.Is] [ErrExecutionAborted],
+// i.f.f. the execution context is done, in which case the EVM is, or will
+// imminently be, [EVM.Cancel]led.
+func (evm *EVM) AbortCause() error {
+	if evm.ctx == nil || evm.ctx.Err() == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrExecutionAborted, context.Cause(evm.ctx))
+}
+
+// abortError returns the error to be returned by the interpreter upon
+// observing that the EVM was cancelled.
+func (evm *EVM) abortError() error {
+	if err := evm.AbortCause(); err != nil {
+		return err
+	}
+	return errStopToken
+}
@@ -26,6 +26,8 @@ import (
 )
 
 type evmArgOverrider struct {
+	NOOPHooks
+
 	newEVMchainID int64
 
 	gotResetChainID  *big.Int
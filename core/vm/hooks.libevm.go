@@ -34,8 +34,47 @@ var libevmHooks Hooks
 type Hooks interface {
 	OverrideNewEVMArgs(*NewEVMArgs) *NewEVMArgs
 	OverrideEVMResetArgs(params.Rules, *EVMResetArgs) *EVMResetArgs
+	// OverrideJumpTable receives a deep copy of the [JumpTable] for the fork
+	// defined by the Rules, after the activation of any [Config.ExtraEips].
+	// The returned table is used by the [EVMInterpreter]; operations MAY be
+	// added or replaced with [OperationBuilder.Build], or removed with
+	// [JumpTable.Undefine].
+	OverrideJumpTable(params.Rules, *JumpTable) *JumpTable
+	// OpInterceptors returns the functions to be called before the execution
+	// of the respective op codes under the Rules. It is called once per
+	// [EVMInterpreter] and op codes that aren't intercepted incur no overhead.
+	OpInterceptors(params.Rules) map[OpCode]OpInterceptor
 }
 
+// An OpInterceptor is called before the execution of an op code, after the
+// stack has been validated but before any gas is charged, so the operation's
+// arguments MAY be inspected on the Stack, which MUST NOT be modified.
+// Returning a non-nil error halts execution of the current frame, reverting its
+// state changes and consuming all of its remaining gas, as with other
+// exceptional halts.
+type OpInterceptor func(OpCode, *EVMInterpreter, *ScopeContext) error
+
+// NOOPHooks implements [Hooks] such that they are equivalent to no hooks
+// having been registered. Implementations that only wish to modify a subset of
+// behaviour SHOULD embed NOOPHooks.
+type NOOPHooks struct{}
+
+var _ Hooks = NOOPHooks{}
+
+// OverrideNewEVMArgs returns the args unchanged.
+func (NOOPHooks) OverrideNewEVMArgs(args *NewEVMArgs) *NewEVMArgs { return args }
+
+// OverrideEVMResetArgs returns the args unchanged.
+func (NOOPHooks) OverrideEVMResetArgs(_ params.Rules, args *EVMResetArgs) *EVMResetArgs {
+	return args
+}
+
+// OverrideJumpTable returns the table unchanged.
+func (NOOPHooks) OverrideJumpTable(_ params.Rules, jt *JumpTable) *JumpTable { return jt }
+
+// OpInterceptors returns nil.
+func (NOOPHooks) OpInterceptors(params.Rules) map[OpCode]OpInterceptor { return nil }
+
 // NewEVMArgs are the arguments received by [NewEVM], available for override
 // via [Hooks].
 type NewEVMArgs struct {
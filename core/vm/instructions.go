@@ -531,7 +531,7 @@ func opSstore(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]b
 
 func opJump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	if interpreter.evm.abort.Load() {
-		return nil, errStopToken
+		return nil, interpreter.evm.abortError() // libevm: was errStopToken
 	}
 	pos := scope.Stack.pop()
 	if !scope.Contract.validJumpdest(&pos) {
@@ -543,7 +543,7 @@ func opJump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byt
 
 func opJumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	if interpreter.evm.abort.Load() {
-		return nil, errStopToken
+		return nil, interpreter.evm.abortError() // libevm: was errStopToken
 	}
 	pos, cond := scope.Stack.pop(), scope.Stack.pop()
 	if !cond.IsZero() {
@@ -801,8 +801,11 @@ func opSelfdestruct(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext
 		return nil, ErrWriteProtection
 	}
 	beneficiary := scope.Stack.pop()
-	balance := interpreter.evm.StateDB.GetBalance(scope.Contract.Address())
-	interpreter.evm.StateDB.AddBalance(beneficiary.Bytes20(), balance)
+	balance, overridden := interpreter.evm.transferNativeBalance(scope.Contract.Address(), beneficiary.Bytes20()) // libevm
+	if !overridden {
+		balance = interpreter.evm.StateDB.GetBalance(scope.Contract.Address())
+		interpreter.evm.StateDB.AddBalance(beneficiary.Bytes20(), balance)
+	}
 	interpreter.evm.StateDB.SelfDestruct(scope.Contract.Address())
 	if tracer := interpreter.evm.Config.Tracer; tracer != nil {
 		tracer.CaptureEnter(SELFDESTRUCT, scope.Contract.Address(), beneficiary.Bytes20(), []byte{}, 0, balance.ToBig())
@@ -816,9 +819,12 @@ func opSelfdestruct6780(pc *uint64, interpreter *EVMInterpreter, scope *ScopeCon
 		return nil, ErrWriteProtection
 	}
 	beneficiary := scope.Stack.pop()
-	balance := interpreter.evm.StateDB.GetBalance(scope.Contract.Address())
-	interpreter.evm.StateDB.SubBalance(scope.Contract.Address(), balance)
-	interpreter.evm.StateDB.AddBalance(beneficiary.Bytes20(), balance)
+	balance, overridden := interpreter.evm.transferNativeBalance(scope.Contract.Address(), beneficiary.Bytes20()) // libevm
+	if !overridden {
+		balance = interpreter.evm.StateDB.GetBalance(scope.Contract.Address())
+		interpreter.evm.StateDB.SubBalance(scope.Contract.Address(), balance)
+		interpreter.evm.StateDB.AddBalance(beneficiary.Bytes20(), balance)
+	}
 	interpreter.evm.StateDB.Selfdestruct6780(scope.Contract.Address())
 	if tracer := interpreter.evm.Config.Tracer; tracer != nil {
 		tracer.CaptureEnter(SELFDESTRUCT, scope.Contract.Address(), beneficiary.Bytes20(), []byte{}, 0, balance.ToBig())
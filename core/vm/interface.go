@@ -21,6 +21,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/holiman/uint256"
 )
@@ -79,6 +80,13 @@ type StateDB interface {
 
 	AddLog(*types.Log)
 	AddPreimage(common.Hash, []byte)
+
+	// libevm: see state.GetExtra(), state.SetExtra(), state.GetSlotExtra(),
+	// and state.SetSlotExtra()
+	GetAccountExtra(common.Address) *pseudo.Type
+	SetAccountExtra(common.Address, *pseudo.Type)
+	GetStorageSlotExtra(common.Address, common.Hash) *pseudo.Type
+	SetStorageSlotExtra(common.Address, common.Hash, *pseudo.Type)
 }
 
 // CallContext provides a basic interface for the EVM calling conventions. The EVM
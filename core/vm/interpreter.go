@@ -49,6 +49,8 @@ type EVMInterpreter struct {
 
 	readOnly   bool   // Whether to throw on stateful modifications
 	returnData []byte // Last CALL's return data for subsequent reuse
+
+	interceptors *[256]OpInterceptor // libevm: nil if no op codes are intercepted
 }
 
 // NewEVMInterpreter returns a new instance of the Interpreter.
@@ -95,7 +97,12 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 		}
 	}
 	evm.Config.ExtraEips = extraEips
-	return &EVMInterpreter{evm: evm, table: table}
+	table = overrideJumpTable(evm.chainRules, table) // libevm
+	return &EVMInterpreter{
+		evm:          evm,
+		table:        table,
+		interceptors: opInterceptors(evm.chainRules), // libevm
+	}
 }
 
 // Run loops and evaluates the contract's code with the given input data and returns
@@ -185,6 +192,11 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		} else if sLen > operation.maxStack {
 			return nil, &ErrStackOverflow{stackLen: sLen, limit: operation.maxStack}
 		}
+		if in.interceptors != nil { // libevm
+			if err := in.interceptOp(op, callContext); err != nil {
+				return nil, err
+			}
+		}
 		if !contract.UseGas(cost) {
 			return nil, ErrOutOfGas
 		}
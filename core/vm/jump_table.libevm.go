@@ -0,0 +1,112 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package vm
+
+import "github.com/ethereum/go-ethereum/params"
+
+// An Operation is a single entry in a [JumpTable]. Operations are constructed
+// with [OperationBuilder.Build].
+type Operation = operation
+
+// An OperationBuilder is a factory for a new [Operation], typically used to
+// add or replace entries in a [JumpTable] via [Hooks.OverrideJumpTable].
+//
+// All function fields have the same semantics as those of native operations.
+// Values on the Stack MAY be modified by embedding it in a [MutableStack].
+type OperationBuilder struct {
+	Execute     func(pc *uint64, _ *EVMInterpreter, _ *ScopeContext) ([]byte, error)
+	ConstantGas uint64
+	// DynamicGas, if non-nil, is charged after ConstantGas and after memory
+	// expansion has been checked; its last argument is the new memory size
+	// requested by MemorySize.
+	DynamicGas func(_ *EVM, _ *Contract, _ *Stack, _ *Memory, requestedMemorySize uint64) (uint64, error)
+	// MemorySize, if non-nil, returns the memory size required by the
+	// operation and whether the calculation overflowed.
+	MemorySize func(*Stack) (size uint64, overflow bool)
+	// Pops and Pushes are the number of items that the operation respectively
+	// removes from and adds to the stack, from which stack bounds are derived.
+	Pops, Pushes int
+}
+
+// Build returns a new Operation.
+func (b OperationBuilder) Build() *Operation {
+	return &operation{
+		execute:     b.Execute,
+		constantGas: b.ConstantGas,
+		dynamicGas:  b.DynamicGas,
+		minStack:    minStack(b.Pops, b.Pushes),
+		maxStack:    maxStack(b.Pops, b.Pushes),
+		memorySize:  b.MemorySize,
+	}
+}
+
+// Undefine replaces the operation for the [OpCode] such that execution
+// results in an [ErrInvalidOpCode], as with op codes that were never defined.
+func (jt *JumpTable) Undefine(op OpCode) {
+	jt[op] = &operation{execute: opUndefined, maxStack: maxStack(0, 0)}
+}
+
+// EVM returns the interpreter's EVM.
+func (in *EVMInterpreter) EVM() *EVM {
+	return in.evm
+}
+
+// ReadOnly returns whether the interpreter is currently executing in a
+// read-only context, in which case operations MUST NOT modify state.
+func (in *EVMInterpreter) ReadOnly() bool {
+	return in.readOnly
+}
+
+// opInterceptors returns the result of [Hooks.OpInterceptors], indexed by op
+// code, or nil if no hooks are registered or no op codes are intercepted.
+func opInterceptors(rules params.Rules) *[256]OpInterceptor {
+	if libevmHooks == nil {
+		return nil
+	}
+	var (
+		fns         [256]OpInterceptor
+		intercepted bool
+	)
+	for op, fn := range libevmHooks.OpInterceptors(rules) {
+		if fn != nil {
+			fns[op] = fn
+			intercepted = true
+		}
+	}
+	if !intercepted {
+		return nil
+	}
+	return &fns
+}
+
+// interceptOp calls the [OpInterceptor] for the op code, if any.
+func (in *EVMInterpreter) interceptOp(op OpCode, scope *ScopeContext) error {
+	if fn := in.interceptors[op]; fn != nil {
+		return fn(op, in, scope)
+	}
+	return nil
+}
+
+// overrideJumpTable returns the result of [Hooks.OverrideJumpTable] if hooks
+// are registered, otherwise it returns the table unchanged. The table passed to
+// the hook is always a deep copy, so it can be modified in place.
+func overrideJumpTable(rules params.Rules, table *JumpTable) *JumpTable {
+	if libevmHooks == nil {
+		return table
+	}
+	return libevmHooks.OverrideJumpTable(rules, copyJumpTable(table))
+}
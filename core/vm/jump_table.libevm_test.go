@@ -0,0 +1,247 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// jumpTableOverrider adds a custom op code and, from London, removes
+// SELFDESTRUCT.
+type jumpTableOverrider struct {
+	NOOPHooks
+	op       OpCode
+	gas      uint64
+	pushes   uint64
+	gotRules []params.Rules
+}
+
+func (o *jumpTableOverrider) OverrideJumpTable(r params.Rules, jt *JumpTable) *JumpTable {
+	o.gotRules = append(o.gotRules, r)
+	jt[o.op] = OperationBuilder{
+		Execute: func(pc *uint64, in *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+			MutableStack{scope.Stack}.Push(uint256.NewInt(o.pushes))
+			return nil, nil
+		},
+		ConstantGas: o.gas,
+		Pushes:      1,
+	}.Build()
+	if r.IsLondon {
+		jt.Undefine(SELFDESTRUCT)
+	}
+	return jt
+}
+
+func (o *jumpTableOverrider) register(t *testing.T) {
+	t.Helper()
+	libevmHooks = nil
+	RegisterHooks(o)
+	t.Cleanup(func() {
+		libevmHooks = nil
+	})
+}
+
+func TestOverrideJumpTable(t *testing.T) {
+	hooks := &jumpTableOverrider{
+		op:     0x0c, // undefined in all forks
+		gas:    7,
+		pushes: 42,
+	}
+	hooks.register(t)
+
+	newEVM := func(c *params.ChainConfig) *EVM {
+		return NewEVM(BlockContext{BlockNumber: big.NewInt(0)}, TxContext{}, nil, c, Config{})
+	}
+	run := func(t *testing.T, evm *EVM, code []byte, gas uint64) (*Contract, []byte, error) {
+		t.Helper()
+		addr := common.HexToAddress("C0DE")
+		contract := NewContract(AccountRef(common.Address{}), AccountRef(addr), new(uint256.Int), gas)
+		contract.SetCallCode(&addr, common.Hash{}, code)
+		ret, err := evm.interpreter.Run(contract, nil, false)
+		return contract, ret, err
+	}
+
+	t.Run("custom_op_code", func(t *testing.T) {
+		evm := newEVM(&params.ChainConfig{})
+		require.NotEmpty(t, hooks.gotRules, "OverrideJumpTable() called")
+		assert.False(t, hooks.gotRules[len(hooks.gotRules)-1].IsLondon, "Rules passed to OverrideJumpTable()")
+
+		code := []byte{
+			byte(hooks.op),
+			byte(PUSH1), 0,
+			byte(MSTORE),
+			byte(PUSH1), 32,
+			byte(PUSH1), 0,
+			byte(RETURN),
+		}
+		const gasLimit = 1e6
+		contract, ret, err := run(t, evm, code, gasLimit)
+		require.NoError(t, err)
+		assert.Equal(t, uint256.NewInt(hooks.pushes).PaddedBytes(32), ret, "return data includes value pushed by custom op code")
+
+		// PUSH1 (x3) + MSTORE + memory expansion of one word
+		const native = 3*GasFastestStep + GasFastestStep + 3
+		assert.Equal(t, uint64(gasLimit-native-hooks.gas), contract.Gas, "gas remaining")
+	})
+
+	t.Run("undefine", func(t *testing.T) {
+		frontier := newEVM(&params.ChainConfig{})
+		assert.True(t, frontier.interpreter.table[SELFDESTRUCT].HasCost(), "SELFDESTRUCT defined before London")
+
+		london := newEVM(params.TestChainConfig)
+		assert.True(t, hooks.gotRules[len(hooks.gotRules)-1].IsLondon, "Rules passed to OverrideJumpTable()")
+
+		code := []byte{byte(PUSH1), 0, byte(SELFDESTRUCT)}
+		_, _, err := run(t, london, code, 1e6)
+		var invalid *ErrInvalidOpCode
+		require.ErrorAs(t, err, &invalid, "SELFDESTRUCT after London")
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		evm := newEVM(&params.ChainConfig{})
+		assert.NotSame(t, &frontierInstructionSet, evm.interpreter.table, "overridden table")
+		assert.False(t, frontierInstructionSet[hooks.op].HasCost(), "native table unmodified")
+	})
+}
+
+var (
+	errProtectedAddress = errors.New("protected address")
+	errTooManySStores   = errors.New("too many SSTOREs")
+)
+
+// opInterceptor blocks BALANCE of a protected address and caps the number of
+// SSTOREs.
+type opInterceptor struct {
+	NOOPHooks
+	protected  common.Address
+	maxSStores int
+	sstores    int
+}
+
+func (o *opInterceptor) OpInterceptors(params.Rules) map[OpCode]OpInterceptor {
+	return map[OpCode]OpInterceptor{
+		BALANCE: func(_ OpCode, _ *EVMInterpreter, scope *ScopeContext) error {
+			if common.Address(scope.Stack.Back(0).Bytes20()) == o.protected {
+				return errProtectedAddress
+			}
+			return nil
+		},
+		SSTORE: func(OpCode, *EVMInterpreter, *ScopeContext) error {
+			o.sstores++
+			if o.sstores > o.maxSStores {
+				return errTooManySStores
+			}
+			return nil
+		},
+	}
+}
+
+func (o *opInterceptor) register(t *testing.T) {
+	t.Helper()
+	libevmHooks = nil
+	RegisterHooks(o)
+	t.Cleanup(func() {
+		libevmHooks = nil
+	})
+}
+
+func TestOpInterceptors(t *testing.T) {
+	t.Run("unregistered", func(t *testing.T) {
+		(&jumpTableOverrider{}).register(t)
+		evm := NewEVM(BlockContext{BlockNumber: big.NewInt(0)}, TxContext{}, nil, &params.ChainConfig{}, Config{})
+		assert.Nil(t, evm.interpreter.interceptors, "interceptors when no op codes intercepted")
+	})
+
+	hooks := &opInterceptor{
+		protected:  common.HexToAddress("DEADBEEF"),
+		maxSStores: 2,
+	}
+	hooks.register(t)
+
+	balanceOf := func(addr common.Address) []byte {
+		code := []byte{byte(PUSH20)}
+		code = append(code, addr.Bytes()...)
+		return append(code, byte(BALANCE), byte(POP))
+	}
+	sstores := func(n int) []byte {
+		var code []byte
+		for i := 0; i < n; i++ {
+			code = append(code, byte(PUSH1), 1, byte(PUSH1), byte(i), byte(SSTORE))
+		}
+		return code
+	}
+
+	tests := []struct {
+		name    string
+		code    []byte
+		wantErr error
+	}{
+		{
+			name: "BALANCE_allowed",
+			code: balanceOf(common.HexToAddress("C0FFEE")),
+		},
+		{
+			name:    "BALANCE_blocked",
+			code:    balanceOf(hooks.protected),
+			wantErr: errProtectedAddress,
+		},
+		{
+			name: "SSTORE_within_limit",
+			code: sstores(2),
+		},
+		{
+			name:    "SSTORE_exceeds_limit",
+			code:    sstores(3),
+			wantErr: errTooManySStores,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks.sstores = 0
+			statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+			require.NoError(t, err, "state.New()")
+			addr := common.HexToAddress("C0DE")
+			statedb.SetCode(addr, tt.code)
+
+			vmctx := BlockContext{
+				BlockNumber: big.NewInt(0),
+				Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+			}
+			evm := NewEVM(vmctx, TxContext{}, statedb, &params.ChainConfig{}, Config{})
+			_, gas, err := evm.Call(AccountRef(common.Address{}), addr, nil, 1e6, new(uint256.Int))
+			require.ErrorIs(t, err, tt.wantErr, "evm.Call()")
+			if tt.wantErr == nil {
+				return
+			}
+			assert.Zero(t, gas, "gas remaining after interception error")
+			assert.Zero(t, statedb.GetState(addr, common.Hash{}), "state reverted after interception error")
+		})
+	}
+}
@@ -16,6 +16,8 @@
 
 package vm
 
+import "github.com/ethereum/go-ethereum/libevm"
+
 // A CallOption modifies the default behaviour of a contract call.
 type CallOption interface {
 	libevmCallOption() // noop to only allow internally defined options
@@ -36,3 +38,15 @@ func WithUNSAFECallerAddressProxying() CallOption {
 type callOptUNSAFECallerAddressProxy struct{}
 
 func (callOptUNSAFECallerAddressProxy) libevmCallOption() {}
+
+// WithAsset results in the value of a [Call] being denominated in the specified
+// asset instead of the native one. The asset MUST be overridden by the
+// [params.RulesHooks.TransferOverride] hook, otherwise only zero-value calls
+// succeed.
+func WithAsset(id libevm.AssetID) CallOption {
+	return callOptAsset(id)
+}
+
+type callOptAsset libevm.AssetID
+
+func (callOptAsset) libevmCallOption() {}
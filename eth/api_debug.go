@@ -250,7 +250,11 @@ func storageRangeAt(statedb *state.StateDB, root common.Hash, address common.Add
 		if err != nil {
 			return StorageRangeResult{}, err
 		}
-		e := storageEntry{Value: common.BytesToHash(content)}
+		value, err := state.DecodeSlotValue(content) // libevm: was common.BytesToHash(content)
+		if err != nil {
+			return StorageRangeResult{}, err
+		}
+		e := storageEntry{Value: value}
 		if preimage := tr.GetKey(it.Key); preimage != nil {
 			preimage := common.BytesToHash(preimage)
 			e.Key = &preimage
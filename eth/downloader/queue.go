@@ -70,6 +70,7 @@ type fetchResult struct {
 	Transactions types.Transactions
 	Receipts     types.Receipts
 	Withdrawals  types.Withdrawals
+	BodyExtra    *types.Body // carries any payload registered with types.RegisterExtras
 }
 
 func newFetchResult(header *types.Header, fastSync bool) *fetchResult {
@@ -774,7 +775,8 @@ func (q *queue) DeliverHeaders(id string, headers []*types.Header, hashes []comm
 // also wakes any threads waiting for data delivery.
 func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, txListHashes []common.Hash,
 	uncleLists [][]*types.Header, uncleListHashes []common.Hash,
-	withdrawalLists [][]*types.Withdrawal, withdrawalListHashes []common.Hash) (int, error) {
+	withdrawalLists [][]*types.Withdrawal, withdrawalListHashes []common.Hash,
+	bodies []*types.Body) (int, error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
@@ -785,6 +787,11 @@ func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, txListH
 		if uncleListHashes[index] != header.UncleHash {
 			return errInvalidBody
 		}
+		if bodies != nil { // libevm
+			if err := types.VerifyBodyExtra(header, bodies[index]); err != nil {
+				return errInvalidBody
+			}
+		}
 		if header.WithdrawalsHash == nil {
 			// nil hash means that withdrawals should not be present in body
 			if withdrawalLists[index] != nil {
@@ -836,6 +843,9 @@ func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, txListH
 		result.Transactions = txLists[index]
 		result.Uncles = uncleLists[index]
 		result.Withdrawals = withdrawalLists[index]
+		if bodies != nil {
+			result.BodyExtra = bodies[index]
+		}
 		result.SetBodyDone()
 	}
 	return q.deliver(id, q.blockTaskPool, q.blockTaskQueue, q.blockPendPool,
@@ -125,6 +125,7 @@ type bodyFilterTask struct {
 	peer         string                 // The source peer of block bodies
 	transactions [][]*types.Transaction // Collection of transactions per block bodies
 	uncles       [][]*types.Header      // Collection of uncles per block bodies
+	bodies       []*types.Body          // libevm: bodies carrying extra payloads, parallel to transactions and uncles; MAY be nil
 	time         time.Time              // Arrival time of the blocks' contents
 }
 
@@ -303,6 +304,13 @@ func (f *BlockFetcher) FilterHeaders(peer string, headers []*types.Header, time
 // FilterBodies extracts all the block bodies that were explicitly requested by
 // the fetcher, returning those that should be handled differently.
 func (f *BlockFetcher) FilterBodies(peer string, transactions [][]*types.Transaction, uncles [][]*types.Header, time time.Time) ([][]*types.Transaction, [][]*types.Header) {
+	txs, uncles, _ := f.filterBodies(peer, transactions, uncles, nil, time)
+	return txs, uncles
+}
+
+// filterBodies is equivalent to FilterBodies, additionally filtering the bodies
+// that carry extra payloads; see types.RegisterExtras. The bodies MAY be nil.
+func (f *BlockFetcher) filterBodies(peer string, transactions [][]*types.Transaction, uncles [][]*types.Header, bodies []*types.Body, time time.Time) ([][]*types.Transaction, [][]*types.Header, []*types.Body) {
 	log.Trace("Filtering bodies", "peer", peer, "txs", len(transactions), "uncles", len(uncles))
 
 	// Send the filter channel to the fetcher
@@ -311,20 +319,20 @@ func (f *BlockFetcher) FilterBodies(peer string, transactions [][]*types.Transac
 	select {
 	case f.bodyFilter <- filter:
 	case <-f.quit:
-		return nil, nil
+		return nil, nil, nil
 	}
 	// Request the filtering of the body list
 	select {
-	case filter <- &bodyFilterTask{peer: peer, transactions: transactions, uncles: uncles, time: time}:
+	case filter <- &bodyFilterTask{peer: peer, transactions: transactions, uncles: uncles, bodies: bodies, time: time}:
 	case <-f.quit:
-		return nil, nil
+		return nil, nil, nil
 	}
 	// Retrieve the bodies remaining after filtering
 	select {
 	case task := <-filter:
-		return task.transactions, task.uncles
+		return task.transactions, task.uncles, task.bodies
 	case <-f.quit:
-		return nil, nil
+		return nil, nil, nil
 	}
 }
 
@@ -541,8 +549,9 @@ func (f *BlockFetcher) loop() {
 					case res := <-resCh:
 						res.Done <- nil
 						// Ignoring withdrawals here, since the block fetcher is not used post-merge.
-						txs, uncles, _ := res.Res.(*eth.BlockBodiesResponse).Unpack()
-						f.FilterBodies(peer, txs, uncles, time.Now())
+						bodies := res.Res.(*eth.BlockBodiesResponse)
+						txs, uncles, _ := bodies.Unpack()
+						f.filterBodies(peer, txs, uncles, *bodies, time.Now()) // libevm: was FilterBodies() without bodies
 
 					case <-timeout.C:
 						// The peer didn't respond in time. The request
@@ -683,10 +692,17 @@ func (f *BlockFetcher) loop() {
 						if txnHash != announce.header.TxHash {
 							continue
 						}
+						var body *types.Body // libevm
+						if task.bodies != nil {
+							body = task.bodies[i]
+						}
+						if types.VerifyBodyExtra(announce.header, body) != nil {
+							continue
+						}
 						// Mark the body matched, reassemble if still unknown
 						matched = true
 						if f.getBlock(hash) == nil {
-							block := types.NewBlockWithHeader(announce.header).WithBody(task.transactions[i], task.uncles[i])
+							block := types.NewBlockWithHeader(announce.header).WithBody(task.transactions[i], task.uncles[i]).WithBodyExtra(body) // libevm: WithBodyExtra()
 							block.ReceivedAt = task.time
 							blocks = append(blocks, block)
 						} else {
@@ -696,6 +712,9 @@ func (f *BlockFetcher) loop() {
 					if matched {
 						task.transactions = append(task.transactions[:i], task.transactions[i+1:]...)
 						task.uncles = append(task.uncles[:i], task.uncles[i+1:]...)
+						if task.bodies != nil { // libevm
+							task.bodies = append(task.bodies[:i], task.bodies[i+1:]...)
+						}
 						i--
 						continue
 					}
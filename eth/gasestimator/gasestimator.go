@@ -104,9 +104,17 @@ func Estimate(ctx context.Context, call *core.Message, opts *Options, gasCap uin
 	// unused access list items). Ever so slightly wasteful, but safer overall.
 	if len(call.Data) == 0 {
 		if call.To != nil && opts.State.GetCodeSize(*call.To) == 0 {
-			failed, _, err := execute(ctx, call, opts, params.TxGas)
+			// libevm: the intrinsic gas of a plain transfer MAY be modified by
+			// hooks, so TxGas is no longer a constant.
+			isPostMerge := opts.Header.Difficulty.Cmp(common.Big0) == 0
+			rules := opts.Config.Rules(opts.Header.Number, isPostMerge, opts.Header.Time)
+			transferGas, err := core.MessageIntrinsicGas(rules, call)
+			if err != nil {
+				return 0, nil, err
+			}
+			failed, _, err := execute(ctx, call, opts, transferGas)
 			if !failed && err == nil {
-				return params.TxGas, nil, nil
+				return transferGas, nil, nil
 			}
 		}
 	}
@@ -213,18 +221,9 @@ func run(ctx context.Context, call *core.Message, opts *Options) (*core.Executio
 		dirtyState = opts.State.Copy()
 		evm        = vm.NewEVM(evmContext, msgContext, dirtyState, opts.Config, vm.Config{NoBaseFee: true})
 	)
-	// Monitor the outer context and interrupt the EVM upon cancellation. To avoid
-	// a dangling goroutine until the outer estimation finishes, create an internal
-	// context for the lifetime of this method call.
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	go func() {
-		<-ctx.Done()
-		evm.Cancel()
-	}()
-	// Execute the call, returning a wrapped error or the result
-	result, err := core.ApplyMessage(evm, call, new(core.GasPool).AddGas(math.MaxUint64))
+	// Execute the call, interrupting the EVM upon cancellation of the outer
+	// context, and returning a wrapped error or the result.
+	result, err := core.ApplyMessageContext(ctx, evm, call, new(core.GasPool).AddGas(math.MaxUint64)) // libevm: was ApplyMessage() and a goroutine calling evm.Cancel()
 	if vmerr := dirtyState.Error(); vmerr != nil {
 		return nil, vmerr
 	}
@@ -233,11 +233,11 @@ type BlockBodiesRLPPacket struct {
 }
 
 // BlockBody represents the data content of a single block.
-type BlockBody struct {
-	Transactions []*types.Transaction // Transactions contained within a block
-	Uncles       []*types.Header      // Uncles contained within a block
-	Withdrawals  []*types.Withdrawal  `rlp:"optional"` // Withdrawals contained within a block
-}
+//
+// It is an alias of [types.Body], which has identical fields, such that the
+// RLP encoding of any extra payload registered with [types.RegisterExtras] is
+// carried over the wire.
+type BlockBody = types.Body
 
 // Unpack retrieves the transactions and uncles from the range packet and returns
 // them in a split flat format that's more consistent with the internal data structures.
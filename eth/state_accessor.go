@@ -233,6 +233,13 @@ func (eth *Ethereum) stateAtTransaction(ctx context.Context, block *types.Block,
 	if err != nil {
 		return nil, vm.BlockContext{}, nil, nil, err
 	}
+	// libevm: pre-block hooks run before the first transaction, as in
+	// [core.StateProcessor.Process], so every transaction sees their effects.
+	preBlockEnv := vm.NewEVM(core.NewEVMBlockContext(block.Header(), eth.blockchain, nil), vm.TxContext{}, statedb, eth.blockchain.Config(), vm.Config{})
+	if err := core.ProcessPreBlockHooks(block.Header(), preBlockEnv); err != nil {
+		release()
+		return nil, vm.BlockContext{}, nil, nil, fmt.Errorf("pre-block hooks: %v", err)
+	}
 	if txIndex == 0 && len(block.Transactions()) == 0 {
 		return nil, vm.BlockContext{}, statedb, release, nil
 	}
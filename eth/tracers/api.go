@@ -267,9 +267,18 @@ func (api *API) traceChain(start, end *types.Block, config *TraceConfig, closed
 				var (
 					signer   = types.MakeSigner(api.backend.ChainConfig(), task.block.Number(), task.block.Time())
 					blockCtx = core.NewEVMBlockContext(task.block.Header(), api.chainContext(ctx), nil)
+					txs      = task.block.Transactions()
 				)
+				// libevm: pre-block hooks, as run by core.StateProcessor.Process()
+				if err := processPreBlockHooks(task.block, blockCtx, task.statedb, api.backend.ChainConfig()); err != nil {
+					log.Warn("Tracing failed", "block", task.block.NumberU64(), "err", err)
+					for i, tx := range txs {
+						task.results[i] = &txTraceResult{TxHash: tx.Hash(), Error: err.Error()}
+					}
+					txs = nil
+				}
 				// Trace all the transactions contained within
-				for i, tx := range task.block.Transactions() {
+				for i, tx := range txs {
 					msg, _ := core.TransactionToMessage(tx, signer, task.block.BaseFee())
 					txctx := &Context{
 						BlockHash:   task.block.Hash(),
@@ -526,6 +535,9 @@ func (api *API) IntermediateRoots(ctx context.Context, hash common.Hash, config
 		vmctx              = core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
 		deleteEmptyObjects = chainConfig.IsEIP158(block.Number())
 	)
+	if err := processPreBlockHooks(block, vmctx, statedb, chainConfig); err != nil { // libevm
+		return nil, err
+	}
 	for i, tx := range block.Transactions() {
 		if err := ctx.Err(); err != nil {
 			return nil, err
@@ -586,6 +598,12 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	}
 	defer release()
 
+	// libevm: pre-block hooks, as run by core.StateProcessor.Process(), are
+	// applied before either the parallel or the sequential tracing.
+	if err := processPreBlockHooks(block, core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil), statedb, api.backend.ChainConfig()); err != nil {
+		return nil, err
+	}
+
 	// JS tracers have high overhead. In this case run a parallel
 	// process that generates states in one thread and traces txes
 	// in separate worker threads.
@@ -756,6 +774,9 @@ func (api *API) standardTraceBlockToFile(ctx context.Context, block *types.Block
 		// Note: This copies the config, to not screw up the main config
 		chainConfig, canon = overrideConfig(chainConfig, config.Overrides)
 	}
+	if err := processPreBlockHooks(block, vmctx, statedb, chainConfig); err != nil { // libevm
+		return nil, err
+	}
 	for i, tx := range block.Transactions() {
 		// Prepare the transaction for un-traced execution
 		var (
@@ -964,16 +985,19 @@ func (api *API) traceTx(ctx context.Context, message *core.Message, txctx *Conte
 	go func() {
 		<-deadlineCtx.Done()
 		if errors.Is(deadlineCtx.Err(), context.DeadlineExceeded) {
-			tracer.Stop(errors.New("execution timeout"))
-			// Stop evm execution. Note cancellation is not necessarily immediate.
-			vmenv.Cancel()
+			tracer.Stop(errExecutionTimeout) // libevm: was errors.New("execution timeout")
+			// EVM execution is stopped by ApplyMessageContext(). Note
+			// cancellation is not necessarily immediate.
 		}
 	}()
 	defer cancel()
 
 	// Call Prepare to clear out the statedb access list
 	statedb.SetTxContext(txctx.TxHash, txctx.TxIndex)
-	if _, err = core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.GasLimit)); err != nil {
+	if _, err = core.ApplyMessageContext(deadlineCtx, vmenv, message, new(core.GasPool).AddGas(message.GasLimit)); err != nil { // libevm: was ApplyMessage() with vmenv.Cancel() upon timeout
+		if isExecutionTimeout(err) { // libevm
+			return timedOutResult(tracer)
+		}
 		return nil, fmt.Errorf("tracing failed: %w", err)
 	}
 	return tracer.GetResult()
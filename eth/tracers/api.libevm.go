@@ -0,0 +1,59 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// processPreBlockHooks calls [core.ProcessPreBlockHooks] with an EVM that has
+// an empty [vm.TxContext], as [core.StateProcessor.Process] does, so that the
+// traced transactions are executed against the same state as when the block
+// was processed. Tracing only inspects per-transaction state so post-block
+// hooks, which have no effect on it, are not run.
+func processPreBlockHooks(block *types.Block, blockCtx vm.BlockContext, statedb *state.StateDB, config *params.ChainConfig) error {
+	vmenv := vm.NewEVM(blockCtx, vm.TxContext{}, statedb, config, vm.Config{})
+	return core.ProcessPreBlockHooks(block.Header(), vmenv)
+}
+
+// errExecutionTimeout is the error with which the tracer is stopped, and which
+// is therefore returned by its GetResult() method, if tracing times out.
+var errExecutionTimeout = errors.New("execution timeout")
+
+// isExecutionTimeout reports whether the error returned by
+// [core.ApplyMessageContext] is due to execution having been aborted because
+// the tracing deadline was exceeded.
+func isExecutionTimeout(err error) bool {
+	return errors.Is(err, vm.ErrExecutionAborted) && errors.Is(err, context.DeadlineExceeded)
+}
+
+// timedOutResult returns the tracer's result after tracing timed out which, as
+// before the EVM observed the tracing context, carries the tracer's
+// [errExecutionTimeout] error instead of [vm.ErrExecutionAborted]. The tracer is
+// stopped again as the goroutine that stops it may not have done so yet.
+func timedOutResult(tracer Tracer) (json.RawMessage, error) {
+	tracer.Stop(errExecutionTimeout)
+	return tracer.GetResult()
+}
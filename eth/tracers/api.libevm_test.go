@@ -0,0 +1,124 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// preBlockHooks store the block number in the first storage slot of `contract`.
+type preBlockHooks struct {
+	core.NOOPHooks
+	contract common.Address
+}
+
+func (h preBlockHooks) PreBlock(_ params.Rules, header *types.Header, evm *vm.EVM) error {
+	evm.StateDB.SetState(h.contract, common.Hash{}, common.BigToHash(header.Number))
+	return nil
+}
+
+func TestTraceBlockPreBlockHooks(t *testing.T) {
+	// Not parallel because of the global hook registration.
+	contract := common.Address{'c', 'o', 'n', 't', 'r', 'a', 'c', 't'}
+	core.RegisterHooks(preBlockHooks{contract: contract})
+	t.Cleanup(core.TestOnlyClearRegisteredHooks)
+
+	accounts := newAccounts(1)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			contract: {
+				// Returns the value of the first storage slot.
+				Code: []byte{
+					byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+					byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+				},
+			},
+		},
+	}
+	const genBlocks = 3
+	signer := types.LatestSigner(genesis.Config)
+	backend := newTestBackend(t, genBlocks, genesis, func(i int, b *core.BlockGen) {
+		tx := types.MustSignNewTx(accounts[0].key, signer, &types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &contract,
+			Gas:      1e6,
+			GasPrice: b.BaseFee(),
+		})
+		b.AddTx(tx)
+	})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	for num := uint64(1); num <= genBlocks; num++ {
+		results, err := api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(num), nil)
+		require.NoErrorf(t, err, "TraceBlockByNumber(%d)", num)
+		require.Len(t, results, 1)
+
+		raw, ok := results[0].Result.(json.RawMessage)
+		require.Truef(t, ok, "%T.Result type", results[0])
+		var got logger.ExecutionResult
+		require.NoError(t, json.Unmarshal(raw, &got), "json.Unmarshal(%T.Result)", results[0])
+		want := fmt.Sprintf("%x", common.BigToHash(new(big.Int).SetUint64(num)))
+		require.Equalf(t, want, got.ReturnValue, "traced return value of block %d; i.e. slot set by pre-block hook", num)
+	}
+}
+
+func TestTraceCallTimeout(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(1)
+	loop := common.Address{'l', 'o', 'o', 'p'}
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			// JUMPDEST; PUSH1 0; JUMP; i.e. an infinite loop.
+			loop: {Code: []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0, byte(vm.JUMP)}},
+		},
+	}
+	backend := newTestBackend(t, 1, genesis, func(int, *core.BlockGen) {})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	timeout := "10ms"
+	config := &TraceCallConfig{
+		TraceConfig: TraceConfig{Timeout: &timeout},
+	}
+	args := ethapi.TransactionArgs{
+		From: &accounts[0].addr,
+		To:   &loop,
+	}
+	_, err := api.TraceCall(context.Background(), args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	require.EqualError(t, err, "execution timeout", "TraceCall() of infinite loop; error returned by tracer")
+}
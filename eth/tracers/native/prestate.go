@@ -160,7 +160,8 @@ func (t *prestateTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64,
 		t.lookupAccount(addr)
 	case op == vm.CREATE:
 		nonce := t.env.StateDB.GetNonce(caller)
-		addr := crypto.CreateAddress(caller, nonce)
+		rules := t.rules()                         // libevm
+		addr := rules.CreateAddress(caller, nonce) // libevm: overridable
 		t.lookupAccount(addr)
 		t.created[addr] = true
 	case stackLen >= 4 && op == vm.CREATE2:
@@ -173,7 +174,8 @@ func (t *prestateTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64,
 		}
 		inithash := crypto.Keccak256(init)
 		salt := stackData[stackLen-4]
-		addr := crypto.CreateAddress2(caller, salt.Bytes32(), inithash)
+		rules := t.rules()                                                                                                 // libevm
+		addr := rules.Create2Address(caller, t.env.StateDB.GetNonce(caller), salt.Bytes32(), common.BytesToHash(inithash)) // libevm: overridable
 		t.lookupAccount(addr)
 		t.created[addr] = true
 	}
@@ -123,6 +123,7 @@ type rpcBlock struct {
 	Transactions []rpcTransaction    `json:"transactions"`
 	UncleHashes  []common.Hash       `json:"uncles"`
 	Withdrawals  []*types.Withdrawal `json:"withdrawals,omitempty"`
+	BodyExtra    hexutil.Bytes       `json:"bodyExtra,omitempty"` // libevm: see types.Block.EncodeBodyExtra()
 }
 
 func (ec *Client) getBlock(ctx context.Context, method string, args ...interface{}) (*types.Block, error) {
@@ -191,7 +192,14 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 		}
 		txs[i] = tx.tx
 	}
-	return types.NewBlockWithHeader(head).WithBody(txs, uncles).WithWithdrawals(body.Withdrawals), nil
+	block, err := types.NewBlockWithHeader(head).WithBody(txs, uncles).WithWithdrawals(body.Withdrawals).WithEncodedBodyExtra(body.BodyExtra) // libevm
+	if err != nil {
+		return nil, fmt.Errorf("decoding body extra: %w", err)
+	}
+	if err := types.VerifyBodyExtra(head, block.Body()); err != nil {
+		return nil, fmt.Errorf("server returned body extra not matching header: %w", err)
+	}
+	return block, nil
 }
 
 // HeaderByHash returns the block header with the given hash.
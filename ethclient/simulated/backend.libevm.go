@@ -0,0 +1,33 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package simulated
+
+import "github.com/ethereum/go-ethereum/common"
+
+// CreateAddress returns the address of a contract deployed by a transaction,
+// under the params.Rules of the pending block, in which the transaction will be
+// included, falling back on the current head if there is no pending block. It
+// implements the bind.ContractAddressDeriver interface.
+func (n *Backend) CreateAddress(from common.Address, nonce uint64) common.Address {
+	bc := n.eth.BlockChain()
+	header := bc.CurrentBlock()
+	if pending := n.eth.Miner().PendingBlock(); pending != nil {
+		header = pending.Header()
+	}
+	rules := bc.Config().Rules(header.Number, header.Difficulty.Sign() == 0, header.Time)
+	return rules.CreateAddress(from, nonce)
+}
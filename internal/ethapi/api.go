@@ -664,6 +664,9 @@ type AccountResult struct {
 	Nonce        hexutil.Uint64  `json:"nonce"`
 	StorageHash  common.Hash     `json:"storageHash"`
 	StorageProof []StorageResult `json:"storageProof"`
+
+	// libevm: payload registered with [types.RegisterExtras].
+	Extra *types.StateAccountExtra `json:"extra,omitempty"`
 }
 
 type StorageResult struct {
@@ -759,6 +762,7 @@ func (s *BlockChainAPI) GetProof(ctx context.Context, address common.Address, st
 		Nonce:        hexutil.Uint64(statedb.GetNonce(address)),
 		StorageHash:  storageRoot,
 		StorageProof: storageProof,
+		Extra:        accountExtra(statedb, address), // libevm
 	}, statedb.Error()
 }
 
@@ -956,6 +960,9 @@ type OverrideAccount struct {
 	Balance   **hexutil.Big                `json:"balance"`
 	State     *map[common.Hash]common.Hash `json:"state"`
 	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+
+	// libevm: payload registered with [types.RegisterExtras].
+	Extra *types.StateAccountExtra `json:"extra"`
 }
 
 // StateOverride is the collection of overridden accounts.
@@ -993,6 +1000,10 @@ func (diff *StateOverride) Apply(state *state.StateDB) error {
 				state.SetState(addr, key, value)
 			}
 		}
+		// libevm: override the account's extra payload.
+		if account.Extra != nil {
+			state.SetAccountExtra(addr, account.Extra.TypeErased())
+		}
 	}
 	// Now finalize the changes. Finalize is normally performed between transactions.
 	// By using finalize, the overrides are semantically behaving as
@@ -1103,23 +1114,16 @@ func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.S
 	}
 	evm := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true}, &blockCtx)
 
-	// Wait for the context to be done and cancel the evm. Even if the
-	// EVM has finished, cancelling may be done (repeatedly)
-	go func() {
-		<-ctx.Done()
-		evm.Cancel()
-	}()
-
-	// Execute the message.
+	// Execute the message, aborting the EVM once the context is done.
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
-	result, err := core.ApplyMessage(evm, msg, gp)
+	result, err := core.ApplyMessageContext(ctx, evm, msg, gp) // libevm: was ApplyMessage() and a goroutine calling evm.Cancel()
 	if err := state.Error(); err != nil {
 		return nil, err
 	}
 
 	// If the timer caused an abort, return an appropriate error message
-	if evm.Cancelled() {
-		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+	if errors.Is(err, vm.ErrExecutionAborted) {
+		return nil, fmt.Errorf("%w (timeout = %v)", err, timeout)
 	}
 	if err != nil {
 		return result, fmt.Errorf("err: %w (supplied gas %d)", err, msg.GasLimit)
@@ -1280,6 +1284,10 @@ func RPCMarshalBlock(block *types.Block, inclTx bool, fullTx bool, config *param
 	if block.Header().WithdrawalsHash != nil {
 		fields["withdrawals"] = block.Withdrawals()
 	}
+	if extra, err := block.EncodeBodyExtra(); err == nil && extra != nil {
+		fields["bodyExtra"] = hexutil.Bytes(extra)
+	}
+	block.Hooks().AddRPCFields(block, fields)
 	return fields
 }
 
@@ -1494,7 +1502,8 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 	if args.To != nil {
 		to = *args.To
 	} else {
-		to = crypto.CreateAddress(args.from(), uint64(*args.Nonce))
+		rules := b.ChainConfig().Rules(header.Number, header.Difficulty.Sign() == 0, header.Time) // libevm
+		to = rules.CreateAddress(args.from(), uint64(*args.Nonce))                                // libevm: overridable
 	}
 	isPostMerge := header.Difficulty.Cmp(common.Big0) == 0
 	// Retrieve the precompiles since they don't need to be added to the access list
@@ -1721,6 +1730,7 @@ func marshalReceipt(receipt *types.Receipt, blockHash common.Hash, blockNumber u
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	receipt.Hooks().AddRPCFields(receipt, fields)
 	return fields
 }
 
@@ -1760,7 +1770,8 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 	}
 
 	if tx.To() == nil {
-		addr := crypto.CreateAddress(from, tx.Nonce())
+		rules := b.ChainConfig().Rules(head.Number, head.Difficulty.Sign() == 0, head.Time) // libevm
+		addr := rules.CreateAddress(from, tx.Nonce())                                       // libevm: overridable
 		log.Info("Submitted contract creation", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "contract", addr.Hex(), "value", tx.Value())
 	} else {
 		log.Info("Submitted transaction", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "recipient", tx.To(), "value", tx.Value())
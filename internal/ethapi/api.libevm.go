@@ -0,0 +1,35 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// accountExtra returns the account's payload registered with
+// [types.RegisterExtras], or nil if no extras are registered.
+func accountExtra(db *state.StateDB, addr common.Address) *types.StateAccountExtra {
+	t := db.GetAccountExtra(addr)
+	if t == nil {
+		return nil
+	}
+	acc := new(types.StateAccount)
+	acc.SetTypeErasedExtra(t)
+	return acc.Extra
+}
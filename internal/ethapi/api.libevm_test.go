@@ -0,0 +1,90 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestStateOverrideExtra(t *testing.T) {
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	payloads := types.RegisterExtras[
+		types.NOOPHeaderHooks, *types.NOOPHeaderHooks,
+		types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks,
+		[]string,
+	]()
+
+	withExtra := common.Address{'e', 'x', 't', 'r', 'a'}
+	withoutExtra := common.Address{'n', 'o', 'n', 'e'}
+
+	var overrides StateOverride
+	in := fmt.Sprintf(`{"%s": {"nonce": "0x1", "extra": ["hello", "world"]}, "%s": {"nonce": "0x1"}}`, withExtra, withoutExtra)
+	require.NoErrorf(t, json.Unmarshal([]byte(in), &overrides), "json.Unmarshal(..., %T)", &overrides)
+
+	db, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err, "state.New()")
+	state.SetExtra(db, payloads, withoutExtra, []string{"untouched"})
+
+	require.NoErrorf(t, overrides.Apply(db), "%T.Apply()", overrides)
+	assert.Equal(t, []string{"hello", "world"}, state.GetExtra(db, payloads, withExtra), "overridden extra")
+	assert.Equal(t, []string{"untouched"}, state.GetExtra(db, payloads, withoutExtra), "extra without override")
+}
+
+func TestGetProofExtra(t *testing.T) {
+	types.TestOnlyClearRegisteredExtras()
+	t.Cleanup(types.TestOnlyClearRegisteredExtras)
+	payloads := types.RegisterExtras[
+		types.NOOPHeaderHooks, *types.NOOPHeaderHooks,
+		types.NOOPBlockBodyHooks, *types.NOOPBlockBodyHooks,
+		[]string,
+	]()
+
+	addr := common.Address{'e', 'x', 't', 'r', 'a'}
+	acc := types.Account{Balance: big.NewInt(1), Nonce: 1}
+	payloads.SetOnGenesisAccount(&acc, []string{"hello", "world"})
+	genesis := &core.Genesis{
+		Config: params.MergedTestChainConfig,
+		Alloc:  types.GenesisAlloc{addr: acc},
+	}
+	api := NewBlockChainAPI(newTestBackend(t, 1, genesis, beacon.New(ethash.NewFaker()), func(i int, b *core.BlockGen) {
+		b.SetPoS()
+	}))
+
+	got, err := api.GetProof(context.Background(), addr, nil, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	require.NoError(t, err, "GetProof()")
+	buf, err := json.Marshal(got)
+	require.NoErrorf(t, err, "json.Marshal(%T)", got)
+	assert.Contains(t, string(buf), `"extra":["hello","world"]`, "JSON-encoded GetProof() result")
+}
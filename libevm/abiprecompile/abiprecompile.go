@@ -0,0 +1,273 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package abiprecompile builds stateful precompiles that dispatch calls to Go
+// handlers according to a Solidity ABI.
+package abiprecompile
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/holiman/uint256"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// A Handler implements a single ABI method. Its arguments are available via
+// [Call.Args], unpacked according to the method's inputs, and the returned
+// values are packed according to the method's outputs.
+//
+// An error returned by [Call.Revert] or [Revert] results in the call being
+// reverted, with the error's data being returned to the caller. All other
+// errors are propagated to the EVM, which consumes all remaining gas.
+type Handler func(*Call) ([]any, error)
+
+// A Method couples a [Handler] with the gas that is charged before it is
+// called. Handlers that have dynamic gas requirements MAY charge more via the
+// [vm.PrecompileEnvironment] gas methods, e.g. UseGas().
+type Method struct {
+	Gas     uint64
+	Handler Handler
+}
+
+// New returns a precompile that dispatches calls to the `methods`, which MUST
+// be keyed by the names of the methods in the ABI; i.e. [abi.Method.Name],
+// which differs from the raw name for overloaded methods. Every ABI method
+// MUST have a respective non-nil [Handler].
+//
+// The returned precompile:
+//
+//   - Reverts, without data, if the input is too short, the method selector is
+//     unknown, or the arguments can't be unpacked;
+//   - Returns [vm.ErrOutOfGas] if the supplied gas is insufficient for
+//     [Method.Gas];
+//   - Reverts, without data, if a non-payable method receives value; and
+//   - Returns [vm.ErrWriteProtection] if a method that is neither `view` nor
+//     `pure` is called in a read-only context.
+func New(contractABI abi.ABI, methods map[string]Method) (vm.PrecompiledStatefulContract, error) {
+	dispatch := make(map[[4]byte]*method, len(contractABI.Methods))
+	for name, m := range contractABI.Methods {
+		impl, ok := methods[name]
+		if !ok || impl.Handler == nil {
+			return nil, fmt.Errorf("no handler for ABI method %q", name)
+		}
+		dispatch[[4]byte(m.ID)] = &method{
+			Method: impl,
+			abi:    m,
+		}
+	}
+	for name := range methods {
+		if _, ok := contractABI.Methods[name]; !ok {
+			return nil, fmt.Errorf("handler for unknown ABI method %q", name)
+		}
+	}
+
+	c := &contract{
+		abi:     contractABI,
+		methods: dispatch,
+	}
+	return c.run, nil
+}
+
+type contract struct {
+	abi     abi.ABI
+	methods map[[4]byte]*method
+}
+
+type method struct {
+	Method
+	abi abi.Method
+}
+
+func (c *contract) run(env vm.PrecompileEnvironment, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+	if len(input) < 4 {
+		return nil, suppliedGas, vm.ErrExecutionReverted
+	}
+	m, ok := c.methods[[4]byte(input[:4])]
+	if !ok {
+		return nil, suppliedGas, vm.ErrExecutionReverted
+	}
+
+	if err := env.UseGas(m.Gas); err != nil {
+		return nil, 0, err
+	}
+
+	value := env.Value()
+	if !m.abi.IsPayable() && !value.IsZero() {
+		return nil, env.Gas(), vm.ErrExecutionReverted
+	}
+	if !m.abi.IsConstant() && env.ReadOnly() {
+		return nil, env.Gas(), vm.ErrWriteProtection
+	}
+
+	args, err := m.abi.Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, env.Gas(), vm.ErrExecutionReverted
+	}
+
+	call := &Call{
+		Env:    env,
+		Method: m.abi,
+		Args:   args,
+		Value:  value,
+		abi:    &c.abi,
+	}
+	out, err := m.Handler(call)
+
+	var rev *RevertError
+	switch {
+	case errors.As(err, &rev):
+		return rev.data, env.Gas(), vm.ErrExecutionReverted
+	case err != nil:
+		return nil, env.Gas(), err
+	}
+
+	ret, err := m.abi.Outputs.Pack(out...)
+	if err != nil {
+		return nil, env.Gas(), fmt.Errorf("packing outputs of %q: %v", m.abi.Name, err)
+	}
+	return ret, env.Gas(), nil
+}
+
+// A Call carries the details of a call to a [Handler].
+type Call struct {
+	Env    vm.PrecompileEnvironment
+	Method abi.Method
+	Args   []any
+	Value  *uint256.Int // equivalent to the CALLVALUE op code
+
+	abi *abi.ABI
+}
+
+// Revert returns an error that, when returned by a [Handler], reverts the
+// call with the ABI-encoded custom Solidity error, `name`, carrying the
+// arguments. If there is no such error in the ABI, or if the arguments can't
+// be packed, the returned error instead results in all gas being consumed.
+func (c *Call) Revert(name string, args ...any) error {
+	e, ok := c.abi.Errors[name]
+	if !ok {
+		return fmt.Errorf("unknown ABI error %q", name)
+	}
+	data, err := e.Inputs.Pack(args...)
+	if err != nil {
+		return fmt.Errorf("packing ABI error %q: %v", name, err)
+	}
+	return &RevertError{
+		data: append(e.ID[:4:4], data...),
+		desc: e.Sig,
+	}
+}
+
+// Emit adds a log of the ABI event, `name`, with the arguments, which MUST be
+// in the same order as the event's inputs (both indexed and non-indexed). No
+// gas is charged by Emit.
+//
+// Emit returns [vm.ErrWriteProtection] if the call is read-only.
+func (c *Call) Emit(name string, args ...any) error {
+	ev, ok := c.abi.Events[name]
+	if !ok {
+		return fmt.Errorf("unknown ABI event %q", name)
+	}
+	if n := len(ev.Inputs); len(args) != n {
+		return fmt.Errorf("ABI event %q has %d inputs; got %d arguments", name, n, len(args))
+	}
+
+	var (
+		topics     []common.Hash
+		indexed    []any
+		nonIndexed []any
+	)
+	if !ev.Anonymous {
+		topics = append(topics, ev.ID)
+	}
+	for i, in := range ev.Inputs {
+		if in.Indexed {
+			indexed = append(indexed, args[i])
+		} else {
+			nonIndexed = append(nonIndexed, args[i])
+		}
+	}
+	for _, arg := range indexed {
+		t, err := abi.MakeTopics([]any{arg})
+		if err != nil {
+			return fmt.Errorf("ABI event %q topic: %v", name, err)
+		}
+		topics = append(topics, t[0][0])
+	}
+	data, err := ev.Inputs.NonIndexed().Pack(nonIndexed...)
+	if err != nil {
+		return fmt.Errorf("packing ABI event %q: %v", name, err)
+	}
+
+	db := c.Env.StateDB()
+	if db == nil {
+		return vm.ErrWriteProtection
+	}
+	db.AddLog(&types.Log{
+		Address:     c.Env.Addresses().Self,
+		Topics:      topics,
+		Data:        data,
+		BlockNumber: c.Env.BlockNumber().Uint64(),
+	})
+	return nil
+}
+
+// A RevertError is returned by [Call.Revert] and [Revert].
+type RevertError struct {
+	data []byte
+	desc string
+}
+
+// Error returns a description of the revert reason.
+func (e *RevertError) Error() string {
+	return fmt.Sprintf("%v: %s", vm.ErrExecutionReverted, e.desc)
+}
+
+// Data returns the ABI-encoded revert data returned to the caller.
+func (e *RevertError) Data() []byte {
+	return common.CopyBytes(e.data)
+}
+
+// revertSelector is the selector of the Solidity `Error(string)` type, used
+// by `revert(string)` and `require(bool, string)`.
+var revertSelector = [4]byte{0x08, 0xc3, 0x79, 0xa0}
+
+var stringArgs = abi.Arguments{{Type: func() abi.Type {
+	t, err := abi.NewType("string", "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}()}}
+
+// Revert returns an error that, when returned by a [Handler], reverts the call
+// with the reason encoded as the Solidity `Error(string)` type; i.e. the same
+// data as `revert(reason)`.
+func Revert(reason string) error {
+	data, err := stringArgs.Pack(reason)
+	if err != nil {
+		// A string can always be packed.
+		panic(err)
+	}
+	return &RevertError{
+		data: append(revertSelector[:], data...),
+		desc: reason,
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package abiprecompile_test
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm"
+	"github.com/ethereum/go-ethereum/libevm/abiprecompile"
+	"github.com/ethereum/go-ethereum/libevm/ethtest"
+	"github.com/ethereum/go-ethereum/libevm/hookstest"
+)
+
+const storeABI = `[
+	{"type":"function","name":"get","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"set","stateMutability":"nonpayable","inputs":[{"name":"val","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"deposit","stateMutability":"payable","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"fail","stateMutability":"pure","inputs":[],"outputs":[]},
+	{"type":"event","name":"Set","anonymous":false,"inputs":[{"name":"who","type":"address","indexed":true},{"name":"val","type":"uint256","indexed":false}]},
+	{"type":"error","name":"TooLarge","inputs":[{"name":"max","type":"uint256"}]}
+]`
+
+const (
+	setGas     = 100
+	maxStored  = 1000
+	failReason = "always fails"
+)
+
+var slot common.Hash
+
+func newStorePrecompile(t *testing.T) (abi.ABI, vm.PrecompiledStatefulContract) {
+	t.Helper()
+	parsed, err := abi.JSON(strings.NewReader(storeABI))
+	require.NoError(t, err, "abi.JSON()")
+
+	p, err := abiprecompile.New(parsed, map[string]abiprecompile.Method{
+		"get": {
+			Handler: func(c *abiprecompile.Call) ([]any, error) {
+				v := c.Env.ReadOnlyState().GetState(c.Env.Addresses().Self, slot)
+				return []any{new(big.Int).SetBytes(v[:])}, nil
+			},
+		},
+		"set": {
+			Gas: setGas,
+			Handler: func(c *abiprecompile.Call) ([]any, error) {
+				val, ok := c.Args[0].(*big.Int)
+				if !ok {
+					return nil, fmt.Errorf("unexpected argument type %T", c.Args[0])
+				}
+				if val.Cmp(big.NewInt(maxStored)) > 0 {
+					return nil, c.Revert("TooLarge", big.NewInt(maxStored))
+				}
+				c.Env.StateDB().SetState(c.Env.Addresses().Self, slot, common.BigToHash(val))
+				return nil, c.Emit("Set", c.Env.Addresses().Caller, val)
+			},
+		},
+		"deposit": {
+			Handler: func(c *abiprecompile.Call) ([]any, error) {
+				return []any{c.Value.ToBig()}, nil
+			},
+		},
+		"fail": {
+			Handler: func(*abiprecompile.Call) ([]any, error) {
+				return nil, abiprecompile.Revert(failReason)
+			},
+		},
+	})
+	require.NoError(t, err, "abiprecompile.New()")
+	return parsed, p
+}
+
+func TestPrecompile(t *testing.T) {
+	rng := ethtest.NewPseudoRand(42)
+	precompile := rng.Address()
+	caller := rng.Address()
+
+	parsed, p := newStorePrecompile(t)
+	hooks := &hookstest.Stub{
+		PrecompileOverrides: map[common.Address]libevm.PrecompiledContract{
+			precompile: vm.NewStatefulPrecompile(p),
+		},
+	}
+	hooks.Register(t)
+
+	pack := func(t *testing.T, method string, args ...any) []byte {
+		t.Helper()
+		b, err := parsed.Pack(method, args...)
+		require.NoErrorf(t, err, "%T.Pack(%q, ...)", parsed, method)
+		return b
+	}
+
+	blockNum := big.NewInt(314159)
+	state, evm := ethtest.NewZeroEVM(t, ethtest.WithBlockContext(vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		BlockNumber: blockNum,
+	}))
+	state.SetBalance(caller, uint256.NewInt(1e6))
+
+	const gasLimit = 1e6
+
+	t.Run("set", func(t *testing.T) {
+		val := big.NewInt(maxStored)
+		_, gasRemaining, err := evm.Call(vm.AccountRef(caller), precompile, pack(t, "set", val), gasLimit, uint256.NewInt(0))
+		require.NoError(t, err, "set()")
+		assert.Equal(t, uint64(gasLimit-setGas), gasRemaining, "gas remaining")
+		assert.Equal(t, common.BigToHash(val), state.GetState(precompile, slot), "stored value")
+
+		logs := state.Logs()
+		require.Len(t, logs, 1, "logs")
+		assert.Equal(t, precompile, logs[0].Address, "log address")
+		assert.Equal(t, []common.Hash{
+			parsed.Events["Set"].ID,
+			common.BytesToHash(caller.Bytes()),
+		}, logs[0].Topics, "log topics")
+		assert.Equal(t, common.BigToHash(val).Bytes(), logs[0].Data, "log data")
+		assert.Equal(t, blockNum.Uint64(), logs[0].BlockNumber, "log block number")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		got, _, err := evm.StaticCall(vm.AccountRef(caller), precompile, pack(t, "get"), gasLimit)
+		require.NoError(t, err, "get()")
+		out, err := parsed.Unpack("get", got)
+		require.NoError(t, err, "Unpack(get)")
+		assert.Equal(t, big.NewInt(maxStored), out[0], "get() after set()")
+	})
+
+	t.Run("deposit", func(t *testing.T) {
+		value := uint256.NewInt(42)
+		got, _, err := evm.Call(vm.AccountRef(caller), precompile, pack(t, "deposit"), gasLimit, value)
+		require.NoError(t, err, "deposit()")
+		out, err := parsed.Unpack("deposit", got)
+		require.NoError(t, err, "Unpack(deposit)")
+		assert.Equal(t, value.ToBig(), out[0], "deposit() returns Call.Value")
+	})
+
+	t.Run("custom_error", func(t *testing.T) {
+		got, gasRemaining, err := evm.Call(vm.AccountRef(caller), precompile, pack(t, "set", big.NewInt(maxStored+1)), gasLimit, uint256.NewInt(0))
+		require.ErrorIs(t, err, vm.ErrExecutionReverted, "set(<too large>)")
+		assert.Equal(t, uint64(gasLimit-setGas), gasRemaining, "gas remaining after revert")
+
+		e := parsed.Errors["TooLarge"]
+		want, err := e.Inputs.Pack(big.NewInt(maxStored))
+		require.NoError(t, err)
+		assert.Equal(t, append(e.ID[:4], want...), got, "revert data")
+	})
+
+	t.Run("revert_reason", func(t *testing.T) {
+		got, _, err := evm.Call(vm.AccountRef(caller), precompile, pack(t, "fail"), gasLimit, uint256.NewInt(0))
+		require.ErrorIs(t, err, vm.ErrExecutionReverted, "fail()")
+		reason, err := abi.UnpackRevert(got)
+		require.NoError(t, err, "abi.UnpackRevert()")
+		assert.Equal(t, failReason, reason)
+	})
+
+	tests := []struct {
+		name             string
+		input            []byte
+		value            *uint256.Int
+		static           bool
+		gas              uint64
+		wantErr          error
+		wantGasRemaining uint64
+	}{
+		{
+			name:             "short_input",
+			input:            []byte{1, 2, 3},
+			wantErr:          vm.ErrExecutionReverted,
+			wantGasRemaining: gasLimit,
+		},
+		{
+			name:             "unknown_selector",
+			input:            crypto.Keccak256([]byte("unknown()"))[:4],
+			wantErr:          vm.ErrExecutionReverted,
+			wantGasRemaining: gasLimit,
+		},
+		{
+			name:             "bad_arguments",
+			input:            pack(t, "set", big.NewInt(1))[:10],
+			wantErr:          vm.ErrExecutionReverted,
+			wantGasRemaining: gasLimit - setGas,
+		},
+		{
+			name:    "out_of_gas",
+			input:   pack(t, "set", big.NewInt(1)),
+			gas:     setGas - 1,
+			wantErr: vm.ErrOutOfGas,
+		},
+		{
+			name:             "non_payable",
+			input:            pack(t, "set", big.NewInt(1)),
+			value:            uint256.NewInt(1),
+			wantErr:          vm.ErrExecutionReverted,
+			wantGasRemaining: gasLimit - setGas,
+		},
+		{
+			name:    "read_only",
+			input:   pack(t, "set", big.NewInt(1)),
+			static:  true,
+			wantErr: vm.ErrWriteProtection,
+			// Errors other than reverts consume all gas.
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gas := tt.gas
+			if gas == 0 {
+				gas = gasLimit
+			}
+			value := tt.value
+			if value == nil {
+				value = new(uint256.Int)
+			}
+
+			var (
+				gasRemaining uint64
+				err          error
+			)
+			if tt.static {
+				_, gasRemaining, err = evm.StaticCall(vm.AccountRef(caller), precompile, tt.input, gas)
+			} else {
+				_, gasRemaining, err = evm.Call(vm.AccountRef(caller), precompile, tt.input, gas, value)
+			}
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantGasRemaining, gasRemaining, "gas remaining")
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(storeABI))
+	require.NoError(t, err, "abi.JSON()")
+
+	noop := abiprecompile.Method{
+		Handler: func(*abiprecompile.Call) ([]any, error) { return nil, nil },
+	}
+	all := func() map[string]abiprecompile.Method {
+		m := make(map[string]abiprecompile.Method)
+		for name := range parsed.Methods {
+			m[name] = noop
+		}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		methods func() map[string]abiprecompile.Method
+	}{
+		{
+			name: "missing_handler",
+			methods: func() map[string]abiprecompile.Method {
+				m := all()
+				delete(m, "get")
+				return m
+			},
+		},
+		{
+			name: "nil_handler",
+			methods: func() map[string]abiprecompile.Method {
+				m := all()
+				m["get"] = abiprecompile.Method{}
+				return m
+			},
+		},
+		{
+			name: "unknown_method",
+			methods: func() map[string]abiprecompile.Method {
+				m := all()
+				m["unknown"] = noop
+				return m
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := abiprecompile.New(parsed, tt.methods())
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = abiprecompile.New(parsed, all())
+	assert.NoError(t, err, "all methods with handlers")
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// The internalise command modifies Go files in place, making exported methods
+// internal.
+//
+// Usage:
+//
+//	internalise -file <filepath> <type>.<method> [<type>.<method> [...]]
+//
+// For example, with file foo.go containing declarations:
+//
+//	func (f *Foo) Bar() { ... }
+//
+//	func (Foo) Baz() { ... }
+//
+// running
+//
+//	internalise -file foo.go Foo.Bar Foo.Baz
+//
+// results in changes to foo.go:
+//
+//	func (f *Foo) bar() { ... }
+//
+//	func (Foo) baz() { ... }
+//
+// It is intended for use in `go:generate` directives, after code generators
+// such as rlpgen and gencodec, to allow libevm to wrap the generated methods
+// with its own exported equivalents.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func main() {
+	file := flag.String("file", "", "File to modify")
+	flag.Parse()
+
+	if err := run(*file, flag.Args()...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(fileName string, args ...string) error {
+	if fileName == "" {
+		return fmt.Errorf("-file flag required")
+	}
+
+	toChange := make(map[string]bool)
+	for _, a := range args {
+		if parts := strings.Split(a, "."); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid <type>.<method> argument %q", a)
+		}
+		toChange[a] = true
+	}
+
+	fset := token.NewFileSet()
+	mode := parser.SkipObjectResolution | parser.ParseComments
+	parsed, err := parser.ParseFile(fset, fileName, nil, mode)
+	if err != nil {
+		return fmt.Errorf("parser.ParseFile(%q): %v", fileName, err)
+	}
+
+	for _, d := range parsed.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if !ok || fn.Recv.NumFields() != 1 {
+			continue
+		}
+
+		var typ string
+		switch t := fn.Recv.List[0].Type.(type) {
+		case *ast.Ident:
+			typ = t.Name
+		case *ast.StarExpr:
+			id, ok := t.X.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			typ = id.Name
+		default:
+			continue
+		}
+
+		name := fmt.Sprintf("%s.%s", typ, fn.Name.Name)
+		if !toChange[name] {
+			continue
+		}
+		delete(toChange, name)
+
+		r, size := utf8.DecodeRuneInString(fn.Name.Name)
+		fn.Name.Name = string(unicode.ToLower(r)) + fn.Name.Name[size:]
+	}
+
+	if len(toChange) > 0 {
+		var missing []string
+		for m := range toChange {
+			missing = append(missing, m)
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("methods not found in %q: %v", fileName, missing)
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if err := format.Node(f, fset, parsed); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
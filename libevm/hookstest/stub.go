@@ -48,6 +48,12 @@ type Stub struct {
 	ActivePrecompilesFn     func([]common.Address) []common.Address
 	CanExecuteTransactionFn func(common.Address, *common.Address, libevm.StateReader) error
 	CanCreateContractFn     func(*libevm.AddressContext, uint64, libevm.StateReader) (uint64, error)
+	CanDeployCodeFn         func(*libevm.AddressContext, []byte, uint64, libevm.StateReader) (uint64, error)
+	DistributeFeesFn        func(libevm.StateDB, *libevm.TransactionFees)
+	IntrinsicGasFn          func(*libevm.Message, uint64) (uint64, error)
+	GasRefundFn             func(gasUsed, refundCounter, defaultQuotient uint64) uint64
+	TransferOverrides       map[libevm.AssetID]libevm.Transferrer
+	ContractAddressFn       func(*libevm.ContractAddressArgs) common.Address
 }
 
 // Register is a convenience wrapper for registering s as both the
@@ -72,6 +78,26 @@ func (s Stub) PrecompileOverride(a common.Address) (libevm.PrecompiledContract,
 	return p, ok
 }
 
+// ContractAddressOverride proxies arguments to the s.ContractAddressFn function
+// if non-nil, otherwise it doesn't override the address.
+func (s Stub) ContractAddressOverride(args *libevm.ContractAddressArgs) (common.Address, bool) {
+	if f := s.ContractAddressFn; f != nil {
+		return f(args), true
+	}
+	return common.Address{}, false
+}
+
+// TransferOverride uses the s.TransferOverrides map, if non-empty, as the
+// canonical source of all overrides. If the map is empty then no assets are
+// overridden.
+func (s Stub) TransferOverride(id libevm.AssetID) (libevm.Transferrer, bool) {
+	if len(s.TransferOverrides) == 0 {
+		return nil, false
+	}
+	t, ok := s.TransferOverrides[id]
+	return t, ok
+}
+
 // ActivePrecompiles proxies arguments to the s.ActivePrecompilesFn function if
 // non-nil, otherwise it acts as a noop.
 func (s Stub) ActivePrecompiles(active []common.Address) []common.Address {
@@ -122,6 +148,43 @@ func (s Stub) CanCreateContract(cc *libevm.AddressContext, gas uint64, sr libevm
 	return gas, nil
 }
 
+// CanDeployCode proxies arguments to the s.CanDeployCodeFn function if non-nil,
+// otherwise it acts as a noop.
+func (s Stub) CanDeployCode(cc *libevm.AddressContext, code []byte, gas uint64, sr libevm.StateReader) (uint64, error) {
+	if f := s.CanDeployCodeFn; f != nil {
+		return f(cc, code, gas, sr)
+	}
+	return gas, nil
+}
+
+// DistributeFees proxies arguments to the s.DistributeFeesFn function if
+// non-nil, otherwise it falls back to the default behaviour.
+func (s Stub) DistributeFees(db libevm.StateDB, f *libevm.TransactionFees) {
+	if fn := s.DistributeFeesFn; fn != nil {
+		fn(db, f)
+		return
+	}
+	params.NOOPHooks{}.DistributeFees(db, f)
+}
+
+// IntrinsicGas proxies arguments to the s.IntrinsicGasFn function if non-nil,
+// otherwise it acts as a noop.
+func (s Stub) IntrinsicGas(msg *libevm.Message, intrinsic uint64) (uint64, error) {
+	if f := s.IntrinsicGasFn; f != nil {
+		return f(msg, intrinsic)
+	}
+	return intrinsic, nil
+}
+
+// GasRefund proxies arguments to the s.GasRefundFn function if non-nil,
+// otherwise it falls back to the default behaviour.
+func (s Stub) GasRefund(gasUsed, refundCounter, defaultQuotient uint64) uint64 {
+	if f := s.GasRefundFn; f != nil {
+		return f(gasUsed, refundCounter, defaultQuotient)
+	}
+	return params.NOOPHooks{}.GasRefund(gasUsed, refundCounter, defaultQuotient)
+}
+
 var _ interface {
 	params.ChainConfigHooks
 	params.RulesHooks
@@ -33,3 +33,6 @@ var (
 
 // StateReader MUST be a subset vm.StateDB.
 var _ libevm.StateReader = (vm.StateDB)(nil)
+
+// StateDB MUST be a subset vm.StateDB.
+var _ libevm.StateDB = (vm.StateDB)(nil)
@@ -16,9 +16,12 @@
 package libevm
 
 import (
+	"math/big"
+
 	"github.com/holiman/uint256"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
 )
 
 // PrecompiledContract is an exact copy of vm.PrecompiledContract, mirrored here
@@ -54,6 +57,11 @@ type StateReader interface {
 
 	AddressInAccessList(addr common.Address) bool
 	SlotInAccessList(addr common.Address, slot common.Hash) (addressOk bool, slotOk bool)
+
+	// GetAccountExtra and GetStorageSlotExtra SHOULD NOT be used directly;
+	// instead use the typed state.GetExtra() and state.GetSlotExtra() helpers.
+	GetAccountExtra(common.Address) *pseudo.Type
+	GetStorageSlotExtra(common.Address, common.Hash) *pseudo.Type
 }
 
 // AddressContext carries addresses available to contexts such as calls and
@@ -66,3 +74,97 @@ type AddressContext struct {
 	Caller common.Address // equivalent to vm.CALLER op code
 	Self   common.Address // equivalent to vm.ADDRESS op code
 }
+
+// StateDB is a subset of vm.StateDB, exposing the methods of [StateReader]
+// along with those required to modify balances and storage. See method
+// comments in vm.StateDB, which aren't copied here as they risk becoming
+// outdated.
+type StateDB interface {
+	StateReader
+
+	AddBalance(common.Address, *uint256.Int)
+	SubBalance(common.Address, *uint256.Int)
+	SetState(common.Address, common.Hash, common.Hash)
+
+	// SetAccountExtra and SetStorageSlotExtra SHOULD NOT be used directly;
+	// instead use the typed state.SetExtra() and state.SetSlotExtra() helpers.
+	SetAccountExtra(common.Address, *pseudo.Type)
+	SetStorageSlotExtra(common.Address, common.Hash, *pseudo.Type)
+}
+
+// ContractAddressArgs carry the values from which the address of a new contract
+// is derived.
+type ContractAddressArgs struct {
+	Caller common.Address
+	// Nonce is the caller's nonce before it is incremented by the creation.
+	Nonce uint64
+	// Salt is non-nil i.f.f. the contract is created with CREATE2, in which
+	// case InitCodeHash is also set.
+	Salt         *common.Hash
+	InitCodeHash common.Hash
+}
+
+// An AssetID identifies an asset that can be held by, and transferred between,
+// accounts. The zero value, [NativeAsset], is the chain's native asset, which
+// is also used to pay for gas.
+type AssetID common.Hash
+
+// NativeAsset is the [AssetID] of the chain's native asset.
+var NativeAsset AssetID
+
+// A Transferrer defines how an asset is held by, and transferred between,
+// accounts. A transfer is a call to CanTransfer, followed by Debit from the
+// sender and Credit to the recipient only if CanTransfer returned true.
+type Transferrer interface {
+	// Balance returns the amount held by the account. It is used wherever the
+	// entire balance is required, e.g. by SELFDESTRUCT and transaction pools.
+	Balance(_ StateReader, account common.Address) *uint256.Int
+	// CanTransfer reports whether at least amount can be debited from the
+	// account.
+	CanTransfer(_ StateReader, from common.Address, amount *uint256.Int) bool
+	Debit(_ StateDB, from common.Address, amount *uint256.Int)
+	Credit(_ StateDB, to common.Address, amount *uint256.Int)
+}
+
+// TransactionFees carries the fees paid by a transaction, all of which have
+// already been deducted from the sender's balance.
+type TransactionFees struct {
+	Coinbase common.Address // equivalent to vm.COINBASE op code
+
+	GasUsed uint64
+	// EffectiveTip is the per-gas amount paid above the BaseFee.
+	EffectiveTip *uint256.Int
+	// BaseFee is the per-gas base fee; it is nil before London.
+	BaseFee *uint256.Int
+
+	BlobGasUsed uint64
+	// BlobBaseFee is the per-blob-gas fee; it is nil before Cancun.
+	BlobBaseFee *uint256.Int
+
+	// NativeTransferrer is the [Transferrer] of the [NativeAsset], in which
+	// the fees are denominated, if it is overridden; otherwise it is nil. See
+	// [TransactionFees.CreditNative].
+	NativeTransferrer Transferrer
+}
+
+// CreditNative credits the account with amount of the [NativeAsset], via the
+// NativeTransferrer if non-nil, otherwise by adding to its balance. Fee
+// distribution MUST use it, or the equivalent, for the native asset to be
+// conserved when its transfer is overridden.
+func (f *TransactionFees) CreditNative(db StateDB, to common.Address, amount *uint256.Int) {
+	if t := f.NativeTransferrer; t != nil {
+		t.Credit(db, to, amount)
+		return
+	}
+	db.AddBalance(to, amount)
+}
+
+// A Message is a subset of the fields of a core.Message, for use by hooks in
+// packages that can't import core.
+type Message struct {
+	From     common.Address
+	To       *common.Address // nil for contract creation
+	Value    *big.Int
+	GasLimit uint64
+	Data     []byte
+}
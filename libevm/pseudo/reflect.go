@@ -46,7 +46,7 @@ func (c *concrete[T]) equal(t *Type) bool {
 	}
 }
 
-func (c *concrete[T]) DecodeRLP(s *rlp.Stream) error {
+func (c *concrete[T]) decodeRLP(s *rlp.Stream) error {
 	switch v := reflect.ValueOf(c.val); v.Kind() {
 	case reflect.Pointer:
 		if v.IsNil() {
@@ -237,9 +237,12 @@ func (c *concrete[T]) mustSet(v any) {
 	_ = 0 // for happy-path coverage inspection
 }
 
-func (c *concrete[T]) MarshalJSON() ([]byte, error) { return json.Marshal(c.val) }
+// The unversioned encoding methods are called by their exported equivalents,
+// which add versioning if registered; see [RegisterVersions].
 
-func (c *concrete[T]) UnmarshalJSON(b []byte) error {
+func (c *concrete[T]) marshalJSON() ([]byte, error) { return json.Marshal(c.val) }
+
+func (c *concrete[T]) unmarshalJSON(b []byte) error {
 	var v T
 	if err := json.Unmarshal(b, &v); err != nil {
 		return err
@@ -248,4 +251,4 @@ func (c *concrete[T]) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-func (c *concrete[T]) EncodeRLP(w io.Writer) error { return rlp.Encode(w, c.val) }
+func (c *concrete[T]) encodeRLP(w io.Writer) error { return rlp.Encode(w, c.val) }
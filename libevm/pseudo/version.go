@@ -0,0 +1,311 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package pseudo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/libevm/testonly"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// RegisterVersions registers a versioned encoding for payloads of type `T`,
+// which is then used for all RLP and JSON (un)marshalling of [Type] and [Value]
+// instances carrying a `T`. It is expected to be called in an `init()`
+// function, before any `T` is encoded or decoded, and MUST NOT be called more
+// than once for the same type.
+//
+// The original, unversioned encoding is considered to be version 0, and
+// `migrations[i]` upgrades a payload from version `i` to version `i+1`. `T` is
+// therefore version `len(migrations)` and the last [Migration] MUST return a
+// `T`. RegisterVersions panics if there are no migrations or if they don't
+// form a chain of types ending in `T`.
+//
+// Payloads are always encoded at the latest version. Decoding an earlier
+// version, including unversioned data, applies all necessary migrations in
+// order, and decoding a later version results in an [ErrFutureVersion]. Data
+// can be rewritten at the latest version with [ReencodeRLP] and
+// [ReencodeJSON].
+//
+// Introducing versioning changes the encoding of every payload so is only
+// supported for types that aren't part of consensus encodings. RegisterVersions
+// panics if `T` has been passed to [RequireUnversioned], and vice versa.
+func RegisterVersions[T any](migrations ...Migration) {
+	key := versionKey[T]{}
+	if _, ok := registeredVersions[key]; ok {
+		panic(fmt.Sprintf("re-registration of versions for %s", typeName[T]()))
+	}
+	if reason, ok := unversionedTypes[key]; ok {
+		panic(fmt.Sprintf("versioning of %s not supported: %s", typeName[T](), reason))
+	}
+	if len(migrations) == 0 {
+		panic(fmt.Sprintf("no migrations registered for %s", typeName[T]()))
+	}
+	for i, m := range migrations[1:] {
+		if prev := migrations[i]; m.from != prev.to {
+			panic(fmt.Sprintf("migration %d from %s does not follow migration %d to %s", i+1, m.fromName, i, prev.toName))
+		}
+	}
+	if last := migrations[len(migrations)-1]; last.to != any(key) {
+		panic(fmt.Sprintf("last migration to %s; MUST be to registered type %s", last.toName, typeName[T]()))
+	}
+	registeredVersions[key] = migrations
+}
+
+// RequireUnversioned marks `T` as having an encoding that MUST NOT change,
+// typically because it is consensus critical (e.g. state-account extras are
+// part of the state root). Subsequent calls to [RegisterVersions] for `T` will
+// panic with a message including `reason`. RequireUnversioned panics if
+// versions have already been registered for `T`.
+//
+// It is intended to be called by the registration functions of packages that
+// include payloads in consensus encodings, and MAY be called more than once.
+func RequireUnversioned[T any](reason string) {
+	key := versionKey[T]{}
+	if _, ok := registeredVersions[key]; ok {
+		panic(fmt.Sprintf("%s already versioned but versioning not supported: %s", typeName[T](), reason))
+	}
+	unversionedTypes[key] = reason
+}
+
+// TestOnlyClearVersions clears all versions previously registered with
+// [RegisterVersions], as well as all types passed to [RequireUnversioned]. It
+// panics if called from a non-testing call stack.
+func TestOnlyClearVersions() {
+	testonly.OrPanic(func() {
+		registeredVersions = make(map[any][]Migration)
+		unversionedTypes = make(map[any]string)
+	})
+}
+
+// registeredVersions and unversionedTypes are keyed by versionKey[T]{}, which
+// is unique for each `T`, thus avoiding the need for reflection.
+var (
+	registeredVersions = make(map[any][]Migration)
+	unversionedTypes   = make(map[any]string)
+)
+
+type versionKey[T any] struct{}
+
+func versionsOf[T any]() (migrations []Migration, versioned bool) {
+	migrations, versioned = registeredVersions[versionKey[T]{}]
+	return migrations, versioned
+}
+
+func typeName[T any]() string {
+	var x T
+	return fmt.Sprintf("%T", &x)[1:] // via a pointer to support interface types
+}
+
+// A Migration upgrades a payload from one version to the next. It can only be
+// constructed with [Migrate].
+type Migration struct {
+	from, to         any // versionKey[From]{} and versionKey[To]{} respectively
+	fromName, toName string
+
+	decodeRLP, decodeJSON func([]byte) (any, error)
+	migrate               func(any) (any, error)
+}
+
+// Migrate returns a [Migration] that upgrades payloads from `From` to `To`
+// with `fn`.
+func Migrate[From, To any](fn func(From) (To, error)) Migration {
+	return Migration{
+		from:     versionKey[From]{},
+		to:       versionKey[To]{},
+		fromName: typeName[From](),
+		toName:   typeName[To](),
+		decodeRLP: func(b []byte) (any, error) {
+			var f From
+			err := rlp.DecodeBytes(b, &f)
+			return f, err
+		},
+		decodeJSON: func(b []byte) (any, error) {
+			var f From
+			err := json.Unmarshal(b, &f)
+			return f, err
+		},
+		migrate: func(f any) (any, error) {
+			return fn(f.(From)) //nolint:forcetypeassert // invariant guaranteed by RegisterVersions
+		},
+	}
+}
+
+// ErrFutureVersion is returned when decoding a payload with a version greater
+// than that of the registered type.
+var ErrFutureVersion = errors.New("payload version greater than registered")
+
+// migrate decodes `payload`, of the specified version, and then upgrades it to
+// a `T` before storing it in `c`.
+func (c *concrete[T]) migrate(migrations []Migration, version uint64, payload []byte, decode func(Migration, []byte) (any, error)) error {
+	val, err := decode(migrations[version], payload)
+	if err != nil {
+		return fmt.Errorf("decoding %s payload version %d: %w", typeName[T](), version, err)
+	}
+	for v := version; v < uint64(len(migrations)); v++ {
+		val, err = migrations[v].migrate(val)
+		if err != nil {
+			return fmt.Errorf("migrating %s payload from version %d: %w", typeName[T](), v, err)
+		}
+	}
+	return c.set(val)
+}
+
+func (c *concrete[T]) checkVersion(migrations []Migration, version uint64) error {
+	if latest := uint64(len(migrations)); version > latest {
+		return fmt.Errorf("%w: %s payload version %d > %d", ErrFutureVersion, typeName[T](), version, latest)
+	}
+	return nil
+}
+
+// rlpVersionMarker is the first element of an RLP list encoding a versioned
+// payload, used to differentiate it from unversioned (version 0) payloads.
+var rlpVersionMarker = []byte{0xfe, 'p', 'v'}
+
+// EncodeRLP encodes the payload, prepending its version if registered. A
+// versioned payload is encoded as an RLP list of the [rlpVersionMarker], the
+// version number, and the unversioned encoding of the payload.
+func (c *concrete[T]) EncodeRLP(w io.Writer) error {
+	migrations, ok := versionsOf[T]()
+	if !ok {
+		return c.encodeRLP(w)
+	}
+	var payload bytes.Buffer
+	if err := c.encodeRLP(&payload); err != nil {
+		return err
+	}
+	return rlp.Encode(w, []any{
+		rlpVersionMarker,
+		uint64(len(migrations)),
+		rlp.RawValue(payload.Bytes()),
+	})
+}
+
+// DecodeRLP decodes the payload, migrating it to the latest version if
+// versioned.
+func (c *concrete[T]) DecodeRLP(s *rlp.Stream) error {
+	migrations, ok := versionsOf[T]()
+	if !ok {
+		return c.decodeRLP(s)
+	}
+	raw, err := s.Raw()
+	if err != nil {
+		return err
+	}
+	version, payload := splitVersionedRLP(raw)
+	if err := c.checkVersion(migrations, version); err != nil {
+		return err
+	}
+	if version == uint64(len(migrations)) {
+		return c.decodeRLP(rlp.NewStream(bytes.NewReader(payload), uint64(len(payload))))
+	}
+	return c.migrate(migrations, version, payload, func(m Migration, b []byte) (any, error) {
+		return m.decodeRLP(b)
+	})
+}
+
+// splitVersionedRLP returns the version and unversioned payload encoded in
+// `b`. If `b` is not a versioned encoding then it is treated as version 0.
+func splitVersionedRLP(b []byte) (version uint64, payload []byte) {
+	kind, content, rest, err := rlp.Split(b)
+	if err != nil || kind != rlp.List || len(rest) > 0 {
+		return 0, b
+	}
+	marker, content, err := rlp.SplitString(content)
+	if err != nil || !bytes.Equal(marker, rlpVersionMarker) {
+		return 0, b
+	}
+	v, content, err := rlp.SplitUint64(content)
+	if err != nil {
+		return 0, b
+	}
+	if _, _, rest, err := rlp.Split(content); err != nil || len(rest) > 0 {
+		return 0, b
+	}
+	return v, content
+}
+
+// versionedJSON is the JSON encoding of a versioned payload. The version is
+// a pointer to differentiate it from unversioned (version 0) payloads.
+type versionedJSON struct {
+	Version *uint64         `json:"libevmPayloadVersion"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// MarshalJSON encodes the payload, wrapping it in a [versionedJSON] if
+// versioned.
+func (c *concrete[T]) MarshalJSON() ([]byte, error) {
+	migrations, ok := versionsOf[T]()
+	if !ok {
+		return c.marshalJSON()
+	}
+	payload, err := c.marshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	version := uint64(len(migrations))
+	return json.Marshal(versionedJSON{&version, payload})
+}
+
+// UnmarshalJSON is the JSON equivalent of [concrete.DecodeRLP].
+func (c *concrete[T]) UnmarshalJSON(b []byte) error {
+	migrations, ok := versionsOf[T]()
+	if !ok {
+		return c.unmarshalJSON(b)
+	}
+	var (
+		version uint64
+		payload = b
+	)
+	var v versionedJSON
+	if err := json.Unmarshal(b, &v); err == nil && v.Version != nil {
+		version, payload = *v.Version, v.Payload
+	}
+	if err := c.checkVersion(migrations, version); err != nil {
+		return err
+	}
+	if version == uint64(len(migrations)) {
+		return c.unmarshalJSON(payload)
+	}
+	return c.migrate(migrations, version, payload, func(m Migration, b []byte) (any, error) {
+		return m.decodeJSON(b)
+	})
+}
+
+// ReencodeRLP decodes `b` as a `T` and re-encodes it, thus upgrading data to
+// the latest version registered with [RegisterVersions]. It is intended for
+// rewriting stored payloads, and is a no-op if `T` is unversioned.
+func ReencodeRLP[T any](b []byte) ([]byte, error) {
+	p := Zero[T]()
+	if err := rlp.DecodeBytes(b, p.Type); err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(p.Type)
+}
+
+// ReencodeJSON is the JSON equivalent of [ReencodeRLP].
+func ReencodeJSON[T any](b []byte) ([]byte, error) {
+	p := Zero[T]()
+	if err := json.Unmarshal(b, p.Type); err != nil {
+		return nil, err
+	}
+	return json.Marshal(p.Type)
+}
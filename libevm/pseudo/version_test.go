@@ -0,0 +1,200 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package pseudo_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+type (
+	payloadV0 struct {
+		X uint64 `json:"x"`
+	}
+	payloadV1 struct {
+		X, Y uint64
+	}
+	payloadV2 struct {
+		Sum  uint64 `json:"sum"`
+		Note string `json:"note"`
+	}
+)
+
+var errOverflow = errors.New("overflow")
+
+func registerPayloadVersions() {
+	pseudo.RegisterVersions[payloadV2](
+		pseudo.Migrate(func(v payloadV0) (payloadV1, error) {
+			return payloadV1{X: v.X, Y: 2 * v.X}, nil
+		}),
+		pseudo.Migrate(func(v payloadV1) (payloadV2, error) {
+			if v.X+v.Y < v.X {
+				return payloadV2{}, errOverflow
+			}
+			return payloadV2{Sum: v.X + v.Y, Note: fmt.Sprintf("%d+%d", v.X, v.Y)}, nil
+		}),
+	)
+}
+
+func TestVersionedEncoding(t *testing.T) {
+	pseudo.TestOnlyClearVersions()
+	t.Cleanup(pseudo.TestOnlyClearVersions)
+
+	// Encodings MUST be generated before registration to be unversioned.
+	v0 := payloadV0{X: 42}
+	v0RLP, err := rlp.EncodeToBytes(pseudo.From(v0).Type)
+	require.NoError(t, err, "rlp.EncodeToBytes(unversioned)")
+	v0JSON, err := json.Marshal(pseudo.From(v0).Type)
+	require.NoError(t, err, "json.Marshal(unversioned)")
+
+	registerPayloadVersions()
+
+	v2 := payloadV2{Sum: 126, Note: "42+84"}
+	decodeRLP := func(t *testing.T, b []byte) (payloadV2, error) {
+		t.Helper()
+		typ, val := pseudo.Zero[payloadV2]().TypeAndValue()
+		err := rlp.DecodeBytes(b, typ)
+		return val.Get(), err
+	}
+	decodeJSON := func(t *testing.T, b []byte) (payloadV2, error) {
+		t.Helper()
+		typ, val := pseudo.Zero[payloadV2]().TypeAndValue()
+		err := json.Unmarshal(b, typ)
+		return val.Get(), err
+	}
+
+	t.Run("migration", func(t *testing.T) {
+		got, err := decodeRLP(t, v0RLP)
+		require.NoError(t, err, "rlp.DecodeBytes(unversioned)")
+		assert.Equal(t, v2, got, "RLP-decoded and migrated payload")
+
+		got, err = decodeJSON(t, v0JSON)
+		require.NoError(t, err, "json.Unmarshal(unversioned)")
+		assert.Equal(t, v2, got, "JSON-decoded and migrated payload")
+	})
+
+	t.Run("round_trip", func(t *testing.T) {
+		buf, err := rlp.EncodeToBytes(pseudo.From(v2).Type)
+		require.NoError(t, err, "rlp.EncodeToBytes(versioned)")
+		assert.NotEqual(t, v0RLP, buf, "versioned RLP encoding differs from unversioned")
+		got, err := decodeRLP(t, buf)
+		require.NoError(t, err, "rlp.DecodeBytes(versioned)")
+		assert.Equal(t, v2, got, "RLP round trip")
+
+		buf, err = json.Marshal(pseudo.From(v2).Type)
+		require.NoError(t, err, "json.Marshal(versioned)")
+		assert.JSONEq(t, `{"libevmPayloadVersion":2,"payload":{"sum":126,"note":"42+84"}}`, string(buf), "versioned JSON")
+		got, err = decodeJSON(t, buf)
+		require.NoError(t, err, "json.Unmarshal(versioned)")
+		assert.Equal(t, v2, got, "JSON round trip")
+	})
+
+	t.Run("reencode", func(t *testing.T) {
+		buf, err := pseudo.ReencodeRLP[payloadV2](v0RLP)
+		require.NoError(t, err, "ReencodeRLP()")
+		want, err := rlp.EncodeToBytes(pseudo.From(v2).Type)
+		require.NoError(t, err, "rlp.EncodeToBytes(versioned)")
+		assert.Equal(t, want, buf, "ReencodeRLP()")
+
+		buf, err = pseudo.ReencodeJSON[payloadV2](v0JSON)
+		require.NoError(t, err, "ReencodeJSON()")
+		assert.JSONEq(t, `{"libevmPayloadVersion":2,"payload":{"sum":126,"note":"42+84"}}`, string(buf), "ReencodeJSON()")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := decodeJSON(t, []byte(`{"libevmPayloadVersion":3,"payload":{}}`))
+		assert.ErrorIs(t, err, pseudo.ErrFutureVersion, "json.Unmarshal(future version)")
+
+		_, err = decodeJSON(t, []byte(`{"libevmPayloadVersion":1,"payload":{"X":1,"Y":18446744073709551615}}`))
+		assert.ErrorIs(t, err, errOverflow, "json.Unmarshal() with failing migration")
+	})
+}
+
+func TestRegisterVersionsPanics(t *testing.T) {
+	pseudo.TestOnlyClearVersions()
+	t.Cleanup(pseudo.TestOnlyClearVersions)
+
+	v0To1 := pseudo.Migrate(func(payloadV0) (payloadV1, error) { return payloadV1{}, nil })
+	v1To2 := pseudo.Migrate(func(payloadV1) (payloadV2, error) { return payloadV2{}, nil })
+
+	tests := []struct {
+		name       string
+		register   func()
+		wantPanics bool
+	}{
+		{
+			name:       "no migrations",
+			register:   func() { pseudo.RegisterVersions[payloadV2]() },
+			wantPanics: true,
+		},
+		{
+			name:       "broken chain",
+			register:   func() { pseudo.RegisterVersions[payloadV2](v0To1, v0To1) },
+			wantPanics: true,
+		},
+		{
+			name:       "chain not ending in registered type",
+			register:   func() { pseudo.RegisterVersions[payloadV2](v0To1) },
+			wantPanics: true,
+		},
+		{
+			name:     "valid",
+			register: func() { pseudo.RegisterVersions[payloadV2](v0To1, v1To2) },
+		},
+		{
+			name:       "re-registration",
+			register:   func() { pseudo.RegisterVersions[payloadV2](v0To1, v1To2) },
+			wantPanics: true,
+		},
+		{
+			name:       "RequireUnversioned after registration",
+			register:   func() { pseudo.RequireUnversioned[payloadV2]("consensus critical") },
+			wantPanics: true,
+		},
+		{
+			name:     "RequireUnversioned",
+			register: func() { pseudo.RequireUnversioned[payloadV1]("consensus critical") },
+		},
+		{
+			name:     "RequireUnversioned again",
+			register: func() { pseudo.RequireUnversioned[payloadV1]("consensus critical") },
+		},
+		{
+			name:       "registration after RequireUnversioned",
+			register:   func() { pseudo.RegisterVersions[payloadV1](v0To1) },
+			wantPanics: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantPanics {
+				assert.Panics(t, tt.register)
+			} else {
+				assert.NotPanics(t, tt.register)
+			}
+		})
+	}
+}
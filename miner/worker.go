@@ -1015,6 +1015,10 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 		vmenv := vm.NewEVM(context, vm.TxContext{}, env.state, w.chainConfig, vm.Config{})
 		core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, vmenv, env.state)
 	}
+	if err := core.ProcessPreBlockHooks(header, w.newSystemEVM(env)); err != nil { // libevm
+		log.Error("Failed to run pre-block hooks", "err", err)
+		return nil, err
+	}
 	return env, nil
 }
 
@@ -1096,6 +1100,9 @@ func (w *worker) generateWork(params *generateParams) *newPayloadResult {
 			log.Warn("Block building is interrupted", "allowance", common.PrettyDuration(w.newpayloadTimeout))
 		}
 	}
+	if err := core.ProcessPostBlockHooks(work.header, w.newSystemEVM(work)); err != nil { // libevm
+		return &newPayloadResult{err: err}
+	}
 	block, err := w.engine.FinalizeAndAssemble(w.chain, work.header, work.state, work.txs, nil, work.receipts, params.withdrawals)
 	if err != nil {
 		return &newPayloadResult{err: err}
@@ -1184,6 +1191,9 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 		// Create a local environment copy, avoid the data race with snapshot state.
 		// https://github.com/ethereum/go-ethereum/issues/24299
 		env := env.copy()
+		if err := core.ProcessPostBlockHooks(env.header, w.newSystemEVM(env)); err != nil { // libevm
+			return err
+		}
 		// Withdrawals are set to nil here, because this is only called in PoW.
 		block, err := w.engine.FinalizeAndAssemble(w.chain, env.header, env.state, env.txs, nil, env.receipts, nil)
 		if err != nil {
@@ -0,0 +1,29 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// newSystemEVM returns an EVM, with an empty transaction context, for passing
+// to the block-level hooks in package core.
+func (w *worker) newSystemEVM(env *environment) *vm.EVM {
+	context := core.NewEVMBlockContext(env.header, w.chain, nil)
+	return vm.NewEVM(context, vm.TxContext{}, env.state, w.chainConfig, vm.Config{})
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// balanceRecorder credits `pre` and `post` with one more than the balance of
+// [testUserAddress] before and after the block's transactions, respectively.
+type balanceRecorder struct {
+	core.NOOPHooks
+	pre, post common.Address
+}
+
+func (r *balanceRecorder) record(evm *vm.EVM, to common.Address) {
+	bal := new(uint256.Int).AddUint64(evm.StateDB.GetBalance(testUserAddress), 1)
+	evm.StateDB.AddBalance(to, bal)
+}
+
+func (r *balanceRecorder) PreBlock(_ params.Rules, _ *types.Header, evm *vm.EVM) error {
+	r.record(evm, r.pre)
+	return nil
+}
+
+func (r *balanceRecorder) PostBlock(_ params.Rules, _ *types.Header, evm *vm.EVM) error {
+	r.record(evm, r.post)
+	return nil
+}
+
+func TestBlockHooks(t *testing.T) {
+	hooks := &balanceRecorder{
+		pre:  common.Address{'p', 'r', 'e'},
+		post: common.Address{'p', 'o', 's', 't'},
+	}
+	core.RegisterHooks(hooks)
+	t.Cleanup(core.TestOnlyClearRegisteredHooks)
+
+	engine := ethash.NewFaker()
+	defer engine.Close()
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	r := w.getSealingBlock(&generateParams{
+		parentHash: b.chain.Genesis().Hash(),
+		timestamp:  uint64(time.Now().Unix()),
+		coinbase:   common.Address{'c', 'o', 'i', 'n'},
+		forceTime:  true,
+	})
+	require.NoError(t, r.err, "getSealingBlock()")
+	require.Len(t, r.block.Transactions(), len(pendingTxs), "transactions in block")
+
+	// The importing chain also runs the hooks, so any difference would result
+	// in a state-root mismatch.
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, b.genesis, nil, engine, vm.Config{}, nil, nil)
+	require.NoError(t, err, "core.NewBlockChain()")
+	defer chain.Stop()
+	_, err = chain.InsertChain(types.Blocks{r.block})
+	require.NoError(t, err, "InsertChain()")
+
+	state, err := chain.State()
+	require.NoError(t, err, "State()")
+	transferred := pendingTxs[0].Value().Uint64()
+	assert.Equal(t, uint256.NewInt(1), state.GetBalance(hooks.pre), "balance credited by PreBlock()")
+	assert.Equal(t, uint256.NewInt(transferred+1), state.GetBalance(hooks.post), "balance credited by PostBlock()")
+}
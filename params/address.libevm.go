@@ -0,0 +1,67 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/libevm"
+)
+
+// CreateAddress returns the address of a contract deployed by the caller with
+// CREATE, or by a contract-creation transaction, honouring the
+// [RulesHooks.ContractAddressOverride] hook.
+func (r *Rules) CreateAddress(caller common.Address, nonce uint64) common.Address {
+	args := &libevm.ContractAddressArgs{
+		Caller: caller,
+		Nonce:  nonce,
+	}
+	if addr, ok := r.Hooks().ContractAddressOverride(args); ok {
+		return addr
+	}
+	return crypto.CreateAddress(caller, nonce)
+}
+
+// Create2Address is the CREATE2 equivalent of [Rules.CreateAddress].
+func (r *Rules) Create2Address(caller common.Address, nonce uint64, salt, initCodeHash common.Hash) common.Address {
+	args := &libevm.ContractAddressArgs{
+		Caller:       caller,
+		Nonce:        nonce,
+		Salt:         &salt,
+		InitCodeHash: initCodeHash,
+	}
+	if addr, ok := r.Hooks().ContractAddressOverride(args); ok {
+		return addr
+	}
+	return crypto.CreateAddress2(caller, salt, initCodeHash[:])
+}
+
+// MayOverrideContractAddresses reports whether the [RulesHooks], if any, might
+// override the default derivation of contract addresses. It returns false if
+// no extras are registered, or if [RegisterNamedExtras] was used and no
+// registrant set [NamedExtras.DerivesContractAddresses]; otherwise, as hooks
+// registered with [RegisterExtras] can't be inspected, it returns true.
+func MayOverrideContractAddresses() bool {
+	switch {
+	case registeredExtras == nil:
+		return false
+	case registeredNamedExtras != nil:
+		return registeredNamedExtras.addressDeriver != nil
+	default:
+		return true
+	}
+}
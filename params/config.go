@@ -911,6 +911,9 @@ type Rules struct {
 
 // Rules ensures c's ChainID is not nil.
 func (c *ChainConfig) Rules(num *big.Int, isMerge bool, timestamp uint64) Rules {
+	if r, ok := c.memoizedRules(num, isMerge, timestamp); ok { // libevm
+		return r
+	}
 	chainID := c.ChainID
 	if chainID == nil {
 		chainID = new(big.Int)
@@ -20,6 +20,7 @@ import (
 	"math/big"
 	"reflect"
 
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/libevm/pseudo"
 	"github.com/ethereum/go-ethereum/libevm/testonly"
 )
@@ -45,6 +46,17 @@ type Extras[C ChainConfigHooks, R RulesHooks] struct {
 	// TODO(arr4n): add the [Rules] to the return signature to make it clearer
 	// that the caller can modify the generated Rules.
 	NewRules func(_ *ChainConfig, _ *Rules, _ C, blockNum *big.Int, isMerge bool, timestamp uint64) R
+	// MemoizeRules, if true, signals that [ChainConfig.Rules] MAY return a
+	// copy of an earlier result for the same *ChainConfig, block number, merge
+	// flag, and timestamp, in which case NewRules isn't called. A bounded
+	// number of results are memoized and concurrent calls with the same
+	// arguments MAY each call NewRules.
+	//
+	// The extra payload is shared by all copies so MUST be treated as
+	// immutable, as MUST a ChainConfig once its Rules() method is first
+	// called. [ExtraPayloads.SetOnRules] replaces, rather than modifies, the
+	// payload so is safe to use.
+	MemoizeRules bool
 }
 
 // RegisterExtras registers the types `C` and `R` such that they are carried as
@@ -66,6 +78,10 @@ type Extras[C ChainConfigHooks, R RulesHooks] struct {
 // Where stated in the interface definitions, they will also be used as hooks to
 // alter Ethereum behaviour; if this isn't desired then they can embed
 // [NOOPHooks] to satisfy either interface.
+//
+// The JSON encoding of the `C` payload can be versioned, along with migrations
+// from earlier versions, with [pseudo.RegisterVersions]; stored chain configs
+// can then be upgraded with rawdb.UpgradeVersionedPayloads().
 func RegisterExtras[C ChainConfigHooks, R RulesHooks](e Extras[C, R]) ExtraPayloads[C, R] {
 	if registeredExtras != nil {
 		panic("re-registration of Extras")
@@ -81,6 +97,9 @@ func RegisterExtras[C ChainConfigHooks, R RulesHooks](e Extras[C, R]) ExtraPaylo
 		newForRules:    e.newForRules,
 		payloads:       payloads,
 	}
+	if e.MemoizeRules {
+		registeredExtras.rulesCache = lru.NewCache[rulesKey, Rules](rulesCacheSize)
+	}
 	return payloads
 }
 
@@ -89,10 +108,12 @@ func RegisterExtras[C ChainConfigHooks, R RulesHooks](e Extras[C, R]) ExtraPaylo
 //
 // In tests it SHOULD be called before every call to [RegisterExtras] and then
 // defer-called afterwards, either directly or via testing.TB.Cleanup(). This is
-// a workaround for the single-call limitation on [RegisterExtras].
+// a workaround for the single-call limitation on [RegisterExtras]. It also
+// clears all registrations via [RegisterNamedExtras].
 func TestOnlyClearRegisteredExtras() {
 	testonly.OrPanic(func() {
 		registeredExtras = nil
+		registeredNamedExtras = nil
 	})
 }
 
@@ -104,6 +125,7 @@ type extraConstructors struct {
 	newChainConfig, newRules func() *pseudo.Type
 	reuseJSONRoot            bool
 	newForRules              func(_ *ChainConfig, _ *Rules, blockNum *big.Int, isMerge bool, timestamp uint64) *pseudo.Type
+	rulesCache               *lru.Cache[rulesKey, Rules] // nil unless memoizing; see [Extras.MemoizeRules]
 	// use top-level hooksFrom<X>() functions instead of these as they handle
 	// instances where no [Extras] were registered.
 	payloads interface {
@@ -151,9 +173,10 @@ type ExtraPayloads[C ChainConfigHooks, R RulesHooks] struct {
 	_ struct{} // make godoc show unexported fields so nobody tries to make their own instance ;)
 }
 
-// FromChainConfig returns the ChainConfig's extra payload.
+// FromChainConfig returns the ChainConfig's extra payload. It doesn't modify
+// the ChainConfig so is safe for concurrent use.
 func (ExtraPayloads[C, R]) FromChainConfig(c *ChainConfig) C {
-	return pseudo.MustNewValue[C](c.extraPayload()).Get()
+	return pseudo.MustNewValue[C](c.extraPayloadOrZero()).Get()
 }
 
 // PointerFromChainConfig returns a pointer to the ChainConfig's extra payload.
@@ -179,9 +202,10 @@ func (e ExtraPayloads[C, R]) hooksFromChainConfig(c *ChainConfig) ChainConfigHoo
 	return e.FromChainConfig(c)
 }
 
-// FromRules returns the Rules' extra payload.
+// FromRules returns the Rules' extra payload. It doesn't modify the Rules so is
+// safe for concurrent use.
 func (ExtraPayloads[C, R]) FromRules(r *Rules) R {
-	return pseudo.MustNewValue[R](r.extraPayload()).Get()
+	return pseudo.MustNewValue[R](r.extraPayloadOrZero()).Get()
 }
 
 // PointerFromRules returns a pointer to the Rules's extra payload. This is
@@ -211,6 +235,7 @@ func (c *ChainConfig) addRulesExtra(r *Rules, blockNum *big.Int, isMerge bool, t
 	if registeredExtras != nil {
 		r.extra = registeredExtras.newForRules(c, r, blockNum, isMerge, timestamp)
 	}
+	c.memoizeRules(r, blockNum, isMerge, timestamp)
 }
 
 // extraPayload returns the ChainConfig's extra payload iff [RegisterExtras] has
@@ -230,6 +255,23 @@ func (c *ChainConfig) extraPayload() *pseudo.Type {
 	return c.extra
 }
 
+// extraPayloadOrZero is equivalent to [ChainConfig.extraPayload] except that,
+// if the payload hasn't been populated, the new value isn't stored.
+func (c *ChainConfig) extraPayloadOrZero() *pseudo.Type {
+	if c.extra == nil && registeredExtras != nil {
+		return registeredExtras.newChainConfig()
+	}
+	return c.extraPayload()
+}
+
+// extraPayloadOrZero is equivalent to [ChainConfig.extraPayloadOrZero].
+func (r *Rules) extraPayloadOrZero() *pseudo.Type {
+	if r.extra == nil && registeredExtras != nil {
+		return registeredExtras.newRules()
+	}
+	return r.extraPayload()
+}
+
 // extraPayload is equivalent to [ChainConfig.extraPayload].
 func (r *Rules) extraPayload() *pseudo.Type {
 	if registeredExtras == nil {
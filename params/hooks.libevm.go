@@ -18,6 +18,8 @@ package params
 import (
 	"math/big"
 
+	"github.com/holiman/uint256"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/libevm"
 )
@@ -41,6 +43,9 @@ type ChainConfigHooks interface {
 // payloads.
 type RulesHooks interface {
 	RulesAllowlistHooks
+	RulesFeeHooks
+	RulesGasHooks
+	RulesTransferHooks
 	// PrecompileOverride signals whether or not the EVM interpreter MUST
 	// override its treatment of the address when deciding if it is a
 	// precompiled contract. If PrecompileOverride returns `true` then the
@@ -54,6 +59,15 @@ type RulesHooks interface {
 	// received slice. The value it returns MUST be consistent with the
 	// behaviour of the PrecompileOverride hook.
 	ActivePrecompiles([]common.Address) []common.Address
+	// ContractAddressOverride signals whether or not the default derivation of
+	// a new contract's address MUST be overridden. If it returns `true` then
+	// the returned address is used in place of crypto.CreateAddress() or
+	// crypto.CreateAddress2(), as appropriate. See [Rules.CreateAddress] and
+	// [Rules.Create2Address]. The hook MUST NOT depend on [Rules.IsMerge], nor
+	// on forks that are only active after the merge (e.g. IsShanghai), as the
+	// merge flag isn't available when deriving receipt fields and is instead
+	// taken from [ChainConfig.TerminalTotalDifficultyPassed].
+	ContractAddressOverride(*libevm.ContractAddressArgs) (_ common.Address, override bool)
 }
 
 // RulesAllowlistHooks are a subset of [RulesHooks] that gate actions, signalled
@@ -62,9 +76,58 @@ type RulesAllowlistHooks interface {
 	// CanCreateContract is called after the deployer's nonce is incremented but
 	// before all other state-modifying actions.
 	CanCreateContract(_ *libevm.AddressContext, gas uint64, _ libevm.StateReader) (gasRemaining uint64, _ error)
+	// CanDeployCode is called after a contract's init code has returned
+	// successfully and passed the default checks (e.g. maximum code size), but
+	// before gas is charged for storing the returned runtime code. It MAY
+	// charge additional gas, and returning an error consumes all remaining
+	// gas, as with other contract-creation failures. Returning more gas than
+	// was supplied is treated as an error.
+	CanDeployCode(_ *libevm.AddressContext, code []byte, gas uint64, _ libevm.StateReader) (gasRemaining uint64, _ error)
 	CanExecuteTransaction(from common.Address, to *common.Address, _ libevm.StateReader) error
 }
 
+// RulesFeeHooks are a subset of [RulesHooks] that determine how transaction
+// fees are credited.
+type RulesFeeHooks interface {
+	// DistributeFees is called at the end of a state transition, after unused
+	// gas has been refunded to the sender, and MUST credit the fees to their
+	// recipients. Any part of the fees that isn't credited is burnt. It isn't
+	// called if fee payment is skipped, as with simulated calls that don't
+	// charge a base fee. If the native asset's transfer is overridden (see
+	// [RulesTransferHooks]) then fees MUST be credited with
+	// [libevm.TransactionFees.CreditNative] instead of StateDB.AddBalance.
+	DistributeFees(libevm.StateDB, *libevm.TransactionFees)
+}
+
+// RulesGasHooks are a subset of [RulesHooks] that modify the gas charged to,
+// and refunded from, transactions.
+type RulesGasHooks interface {
+	// IntrinsicGas receives the intrinsic gas of the message, as computed by
+	// core.IntrinsicGas(), and returns the amount to charge. A non-nil error
+	// invalidates the message.
+	IntrinsicGas(_ *libevm.Message, intrinsic uint64) (uint64, error)
+	// GasRefund returns the amount of gas to refund at the end of a state
+	// transition, given the gas used, the value of the state's refund counter,
+	// and the default refund quotient for the fork (see
+	// [RefundQuotientEIP3529]). The refund is capped at gasUsed.
+	GasRefund(gasUsed, refundCounter, defaultQuotient uint64) uint64
+}
+
+// RulesTransferHooks are a subset of [RulesHooks] that define how assets are
+// held by, and transferred between, accounts.
+type RulesTransferHooks interface {
+	// TransferOverride signals whether or not the EVM MUST override its
+	// treatment of the asset. If TransferOverride returns `true` then the
+	// [libevm.Transferrer] is used for all transfers of the asset. In the
+	// case of [libevm.NativeAsset] this includes the purchase and refund of
+	// gas, fee distribution via [libevm.TransactionFees.CreditNative],
+	// SELFDESTRUCT, and the balance checks of transaction pools.
+	// Otherwise the native asset is transferred with vm.BlockContext.Transfer
+	// and all other assets are considered to be unsupported, with any
+	// attempted transfer of a non-zero amount failing.
+	TransferOverride(libevm.AssetID) (_ libevm.Transferrer, override bool)
+}
+
 // Hooks returns the hooks registered with [RegisterExtras], or [NOOPHooks] if
 // none were registered.
 func (c *ChainConfig) Hooks() ChainConfigHooks {
@@ -121,6 +184,12 @@ func (NOOPHooks) CanCreateContract(_ *libevm.AddressContext, gas uint64, _ libev
 	return gas, nil
 }
 
+// CanDeployCode allows all (otherwise valid) runtime code, not consuming any
+// more gas.
+func (NOOPHooks) CanDeployCode(_ *libevm.AddressContext, _ []byte, gas uint64, _ libevm.StateReader) (uint64, error) {
+	return gas, nil
+}
+
 // PrecompileOverride instructs the EVM interpreter to use the default
 // precompile behaviour.
 func (NOOPHooks) PrecompileOverride(common.Address) (libevm.PrecompiledContract, bool) {
@@ -131,3 +200,36 @@ func (NOOPHooks) PrecompileOverride(common.Address) (libevm.PrecompiledContract,
 func (NOOPHooks) ActivePrecompiles(active []common.Address) []common.Address {
 	return active
 }
+
+// ContractAddressOverride instructs the EVM to use the default address
+// derivation.
+func (NOOPHooks) ContractAddressOverride(*libevm.ContractAddressArgs) (common.Address, bool) {
+	return common.Address{}, false
+}
+
+// TransferOverride instructs the EVM to use the default transfer behaviour.
+func (NOOPHooks) TransferOverride(libevm.AssetID) (libevm.Transferrer, bool) {
+	return nil, false
+}
+
+// DistributeFees credits the effective tip to the coinbase, burning the base
+// and blob fees.
+func (NOOPHooks) DistributeFees(db libevm.StateDB, f *libevm.TransactionFees) {
+	fee := new(uint256.Int).SetUint64(f.GasUsed)
+	fee.Mul(fee, f.EffectiveTip)
+	f.CreditNative(db, f.Coinbase, fee)
+}
+
+// IntrinsicGas returns the default intrinsic gas unchanged.
+func (NOOPHooks) IntrinsicGas(_ *libevm.Message, intrinsic uint64) (uint64, error) {
+	return intrinsic, nil
+}
+
+// GasRefund returns the refund counter, capped at gasUsed / defaultQuotient.
+func (NOOPHooks) GasRefund(gasUsed, refundCounter, defaultQuotient uint64) uint64 {
+	refund := gasUsed / defaultQuotient
+	if refund > refundCounter {
+		refund = refundCounter
+	}
+	return refund
+}
@@ -0,0 +1,545 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/libevm"
+	"github.com/ethereum/go-ethereum/libevm/pseudo"
+)
+
+// NamedExtras are arguments to [RegisterNamedExtras].
+type NamedExtras[C ChainConfigHooks, R RulesHooks] struct {
+	// NewRules is equivalent to [Extras.NewRules], receiving only the named
+	// payloads.
+	NewRules func(_ *ChainConfig, _ *Rules, _ C, blockNum *big.Int, isMerge bool, timestamp uint64) R
+	// Precompiles are the only addresses for which the `R` payload's
+	// [RulesHooks.PrecompileOverride] hook will be called. The same address
+	// MUST NOT be declared by more than one registrant.
+	Precompiles []common.Address
+	// DistributesFees signals that the `R` payload's
+	// [RulesFeeHooks.DistributeFees] hook will be called. It MUST NOT be set
+	// by more than one registrant and, if none set it, fees are distributed
+	// as per [NOOPHooks].
+	DistributesFees bool
+	// RefundsGas is the [RulesGasHooks.GasRefund] equivalent of
+	// DistributesFees.
+	RefundsGas bool
+	// Assets are the [RulesTransferHooks.TransferOverride] equivalent of
+	// Precompiles.
+	Assets []libevm.AssetID
+	// DerivesContractAddresses is the [RulesHooks.ContractAddressOverride]
+	// equivalent of DistributesFees.
+	DerivesContractAddresses bool
+	// MemoizeRules is equivalent to [Extras.MemoizeRules], except that
+	// memoization is only enabled if all registrants set it.
+	MemoizeRules bool
+}
+
+// RegisterNamedExtras is equivalent to [RegisterExtras] except that it MAY be
+// called multiple times, by independent modules, with different names. It MUST
+// NOT be called if [RegisterExtras] has been called, and vice versa. As with
+// RegisterExtras, both `C` and `R` MUST be structs or pointers to structs.
+//
+// The payloads of each registrant are carried independently in [ChainConfig]
+// and [Rules] structs. JSON (un)marshalling of a [ChainConfig] uses the "extra"
+// key, which is an object with a sub-key for each name.
+//
+// The hooks of all registrants are combined, in order of registration, such
+// that:
+//   - [ChainConfigHooks] errors are those of the first registrant to return
+//     one, and descriptions are concatenated;
+//   - [RulesAllowlistHooks] block an action if any registrant does, and gas
+//     consumed by CanCreateContract and CanDeployCode is cumulative;
+//   - [RulesHooks.ActivePrecompiles] is piped through all registrants; and
+//   - [RulesHooks.PrecompileOverride] is only called on the registrant that
+//     declared the address in [NamedExtras.Precompiles]; and
+//   - [RulesTransferHooks.TransferOverride] is only called on the registrant
+//     that declared the asset in [NamedExtras.Assets]; and
+//   - [RulesFeeHooks.DistributeFees], [RulesGasHooks.GasRefund], and
+//     [RulesHooks.ContractAddressOverride] are only called on the registrant
+//     that set [NamedExtras.DistributesFees], [NamedExtras.RefundsGas], and
+//     [NamedExtras.DerivesContractAddresses], respectively; and
+//   - [RulesGasHooks.IntrinsicGas] is piped through all registrants, with the
+//     first error being returned.
+//
+// RegisterNamedExtras panics if the name is empty or already registered, if
+// any of the precompile addresses or assets were declared by another
+// registrant, or if another registrant already distributes fees, refunds gas,
+// or derives contract addresses.
+func RegisterNamedExtras[C ChainConfigHooks, R RulesHooks](name string, e NamedExtras[C, R]) NamedExtraPayloads[C, R] {
+	switch {
+	case name == "":
+		panic("empty name for NamedExtras")
+	case registeredExtras != nil && registeredNamedExtras == nil:
+		panic("RegisterNamedExtras() called after RegisterExtras()")
+	}
+	for _, r := range registeredNamedExtras.inOrder() {
+		if r.name == name {
+			panic(fmt.Sprintf("re-registration of NamedExtras %q", name))
+		}
+	}
+	mustBeStructOrPointerToOne[C]()
+	mustBeStructOrPointerToOne[R]()
+
+	reg := &namedRegistration{
+		name:           name,
+		newChainConfig: pseudo.NewConstructor[C]().Zero,
+		newRules:       pseudo.NewConstructor[R]().Zero,
+		newForRules: func(c *ChainConfig, r *Rules, cExtra *pseudo.Type, blockNum *big.Int, isMerge bool, timestamp uint64) *pseudo.Type {
+			if e.NewRules == nil {
+				return pseudo.Zero[R]().Type
+			}
+			cc := pseudo.MustNewValue[C](cExtra).Get()
+			return pseudo.From(e.NewRules(c, r, cc, blockNum, isMerge, timestamp)).Type
+		},
+		chainConfigHooks: func(t *pseudo.Type) ChainConfigHooks {
+			return pseudo.MustNewValue[C](t).Get()
+		},
+		rulesHooks: func(t *pseudo.Type) RulesHooks {
+			return pseudo.MustNewValue[R](t).Get()
+		},
+	}
+
+	if registeredNamedExtras == nil {
+		registeredNamedExtras = &namedExtras{
+			precompiles: make(map[common.Address]*namedRegistration),
+			assets:      make(map[libevm.AssetID]*namedRegistration),
+		}
+		registeredExtras = &extraConstructors{
+			newChainConfig: newNamedChainConfigExtras,
+			newRules:       newZeroNamedRulesExtras,
+			newForRules:    newNamedRulesExtras,
+			payloads:       namedPayloads,
+			rulesCache:     lru.NewCache[rulesKey, Rules](rulesCacheSize),
+		}
+	}
+	for _, addr := range e.Precompiles {
+		if other, ok := registeredNamedExtras.precompiles[addr]; ok {
+			panic(fmt.Sprintf("precompile %v of NamedExtras %q already declared by %q", addr, name, other.name))
+		}
+	}
+	for _, id := range e.Assets {
+		if other, ok := registeredNamedExtras.assets[id]; ok {
+			panic(fmt.Sprintf("asset %#x of NamedExtras %q already declared by %q", id, name, other.name))
+		}
+	}
+	if e.DistributesFees {
+		if other := registeredNamedExtras.feeDistributor; other != nil {
+			panic(fmt.Sprintf("NamedExtras %q distributes fees but so does %q", name, other.name))
+		}
+	}
+	if e.RefundsGas {
+		if other := registeredNamedExtras.gasRefunder; other != nil {
+			panic(fmt.Sprintf("NamedExtras %q refunds gas but so does %q", name, other.name))
+		}
+	}
+	if e.DerivesContractAddresses {
+		if other := registeredNamedExtras.addressDeriver; other != nil {
+			panic(fmt.Sprintf("NamedExtras %q derives contract addresses but so does %q", name, other.name))
+		}
+	}
+	for _, addr := range e.Precompiles {
+		registeredNamedExtras.precompiles[addr] = reg
+	}
+	for _, id := range e.Assets {
+		registeredNamedExtras.assets[id] = reg
+	}
+	if e.DistributesFees {
+		registeredNamedExtras.feeDistributor = reg
+	}
+	if e.RefundsGas {
+		registeredNamedExtras.gasRefunder = reg
+	}
+	if e.DerivesContractAddresses {
+		registeredNamedExtras.addressDeriver = reg
+	}
+	if !e.MemoizeRules {
+		registeredExtras.rulesCache = nil
+	}
+	registeredNamedExtras.registrants = append(registeredNamedExtras.registrants, reg)
+
+	return NamedExtraPayloads[C, R]{reg: reg}
+}
+
+// registeredNamedExtras holds all registrations via [RegisterNamedExtras]. If
+// non-nil then so too is `registeredExtras`, which holds the combined payloads.
+var registeredNamedExtras *namedExtras
+
+type namedExtras struct {
+	registrants    []*namedRegistration
+	precompiles    map[common.Address]*namedRegistration
+	assets         map[libevm.AssetID]*namedRegistration
+	feeDistributor *namedRegistration
+	gasRefunder    *namedRegistration
+	addressDeriver *namedRegistration
+}
+
+func (n *namedExtras) inOrder() []*namedRegistration {
+	if n == nil {
+		return nil
+	}
+	return n.registrants
+}
+
+// A namedRegistration holds non-generic constructors and hook accessors for a
+// single call to [RegisterNamedExtras].
+type namedRegistration struct {
+	name                     string
+	newChainConfig, newRules func() *pseudo.Type
+	newForRules              func(_ *ChainConfig, _ *Rules, cExtra *pseudo.Type, blockNum *big.Int, isMerge bool, timestamp uint64) *pseudo.Type
+	chainConfigHooks         func(*pseudo.Type) ChainConfigHooks
+	rulesHooks               func(*pseudo.Type) RulesHooks
+}
+
+// NamedExtraPayloads is the [RegisterNamedExtras] equivalent of
+// [ExtraPayloads]. The only valid way to construct an instance is by a call to
+// RegisterNamedExtras.
+type NamedExtraPayloads[C ChainConfigHooks, R RulesHooks] struct {
+	reg *namedRegistration
+}
+
+// FromChainConfig returns the ChainConfig's extra payload. It doesn't modify
+// the ChainConfig so is safe for concurrent use.
+func (e NamedExtraPayloads[C, R]) FromChainConfig(c *ChainConfig) C {
+	return pseudo.MustNewValue[C](namedPayloads.FromChainConfig(c).get(e.reg, e.reg.newChainConfig)).Get()
+}
+
+// PointerFromChainConfig is the [NamedExtraPayloads] equivalent of
+// [ExtraPayloads.PointerFromChainConfig]. As the payload might first have to
+// be stored, it MUST NOT be called concurrently with other accesses to the
+// same ChainConfig.
+func (e NamedExtraPayloads[C, R]) PointerFromChainConfig(c *ChainConfig) *C {
+	all := namedPayloads.PointerFromChainConfig(c)
+	return pseudo.MustPointerTo[C](all.getOrSet(e.reg, e.reg.newChainConfig)).Value.Get()
+}
+
+// SetOnChainConfig sets the ChainConfig's extra payload.
+func (e NamedExtraPayloads[C, R]) SetOnChainConfig(c *ChainConfig, val C) {
+	namedPayloads.PointerFromChainConfig(c).set(e.reg.name, pseudo.From(val).Type)
+}
+
+// FromRules returns the Rules' extra payload. It doesn't modify the Rules so is
+// safe for concurrent use.
+func (e NamedExtraPayloads[C, R]) FromRules(r *Rules) R {
+	return pseudo.MustNewValue[R](namedPayloads.FromRules(r).get(e.reg, e.reg.newRules)).Get()
+}
+
+// PointerFromRules is the [NamedExtraPayloads] equivalent of
+// [ExtraPayloads.PointerFromRules], with the same concurrency caveat as
+// [NamedExtraPayloads.PointerFromChainConfig].
+func (e NamedExtraPayloads[C, R]) PointerFromRules(r *Rules) *R {
+	all := namedPayloads.PointerFromRules(r)
+	return pseudo.MustPointerTo[R](all.getOrSet(e.reg, e.reg.newRules)).Value.Get()
+}
+
+// SetOnRules sets the Rules' extra payload. The payloads of all registrants
+// are copied, not modified in place, as they MAY be shared with memoized copies
+// of the Rules (see [NamedExtras.MemoizeRules]), which are unaffected.
+func (e NamedExtraPayloads[C, R]) SetOnRules(r *Rules, val R) {
+	all := namedPayloads.FromRules(r).with(e.reg.name, pseudo.From(val).Type)
+	namedPayloads.SetOnRules(r, namedRulesExtras{all})
+}
+
+// namedPayloads is the accessor for the combined payloads of all named
+// registrants, which are registered as regular [Extras].
+var namedPayloads ExtraPayloads[namedChainConfigExtras, namedRulesExtras]
+
+// namedPseudoTypes maps registrant names to their respective payloads.
+type namedPseudoTypes struct {
+	payloads map[string]*pseudo.Type
+}
+
+func (n *namedPseudoTypes) set(name string, t *pseudo.Type) {
+	if n.payloads == nil {
+		n.payloads = make(map[string]*pseudo.Type)
+	}
+	n.payloads[name] = t
+}
+
+// with returns a copy of n with the named payload set, leaving n unmodified.
+func (n namedPseudoTypes) with(name string, t *pseudo.Type) namedPseudoTypes {
+	c := namedPseudoTypes{
+		payloads: make(map[string]*pseudo.Type, len(n.payloads)+1),
+	}
+	for k, v := range n.payloads {
+		c.payloads[k] = v
+	}
+	c.payloads[name] = t
+	return c
+}
+
+// get returns the named payload, or a new zero value, which is not stored, if
+// none exists.
+func (n namedPseudoTypes) get(r *namedRegistration, zero func() *pseudo.Type) *pseudo.Type {
+	if t, ok := n.payloads[r.name]; ok {
+		return t
+	}
+	return zero()
+}
+
+// getOrSet is equivalent to get() except that a new zero value is stored.
+// Payloads are populated for all registrants upon construction of a
+// namedPseudoTypes so this only stores values for registrants added later.
+func (n *namedPseudoTypes) getOrSet(r *namedRegistration, zero func() *pseudo.Type) *pseudo.Type {
+	if t, ok := n.payloads[r.name]; ok {
+		return t
+	}
+	t := zero()
+	n.set(r.name, t)
+	return t
+}
+
+// newNamedPseudoTypes returns a namedPseudoTypes populated with zero values
+// for all registrants, such that read-only accesses never have to store
+// values.
+func newNamedPseudoTypes(zero func(*namedRegistration) *pseudo.Type) namedPseudoTypes {
+	var n namedPseudoTypes
+	for _, r := range registeredNamedExtras.inOrder() {
+		n.set(r.name, zero(r))
+	}
+	return n
+}
+
+// newNamedChainConfigExtras is the [extraConstructors] `newChainConfig`
+// function for named registrations.
+func newNamedChainConfigExtras() *pseudo.Type {
+	return pseudo.From(namedChainConfigExtras{
+		newNamedPseudoTypes(func(r *namedRegistration) *pseudo.Type { return r.newChainConfig() }),
+	}).Type
+}
+
+// newZeroNamedRulesExtras is the [extraConstructors] `newRules` function for
+// named registrations.
+func newZeroNamedRulesExtras() *pseudo.Type {
+	return pseudo.From(namedRulesExtras{
+		newNamedPseudoTypes(func(r *namedRegistration) *pseudo.Type { return r.newRules() }),
+	}).Type
+}
+
+// namedChainConfigExtras is the [ChainConfig] payload carrying the payloads of
+// all named registrants, combining their hooks.
+type namedChainConfigExtras struct {
+	namedPseudoTypes
+}
+
+// namedRulesExtras is the [Rules] equivalent of namedChainConfigExtras.
+type namedRulesExtras struct {
+	namedPseudoTypes
+}
+
+var _ interface {
+	ChainConfigHooks
+	json.Marshaler
+} = namedChainConfigExtras{}
+
+var _ json.Unmarshaler = (*namedChainConfigExtras)(nil)
+
+var _ RulesHooks = namedRulesExtras{}
+
+func newNamedRulesExtras(c *ChainConfig, r *Rules, blockNum *big.Int, isMerge bool, timestamp uint64) *pseudo.Type {
+	cc := namedPayloads.FromChainConfig(c)
+	var rules namedRulesExtras
+	for _, reg := range registeredNamedExtras.inOrder() {
+		rules.set(reg.name, reg.newForRules(c, r, cc.get(reg, reg.newChainConfig), blockNum, isMerge, timestamp))
+	}
+	return pseudo.From(rules).Type
+}
+
+func (e namedChainConfigExtras) hooks(r *namedRegistration) ChainConfigHooks {
+	return r.chainConfigHooks(e.get(r, r.newChainConfig))
+}
+
+func (e namedRulesExtras) hooks(r *namedRegistration) RulesHooks {
+	return r.rulesHooks(e.get(r, r.newRules))
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (e namedChainConfigExtras) MarshalJSON() ([]byte, error) {
+	all := make(map[string]*pseudo.Type)
+	for _, r := range registeredNamedExtras.inOrder() {
+		all[r.name] = e.get(r, r.newChainConfig)
+	}
+	return json.Marshal(all)
+}
+
+// UnmarshalJSON implements the [json.Unmarshaler] interface.
+func (e *namedChainConfigExtras) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, r := range registeredNamedExtras.inOrder() {
+		t := r.newChainConfig()
+		e.set(r.name, t) // even if absent from the JSON; see newNamedPseudoTypes()
+		buf, ok := raw[r.name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(buf, t); err != nil {
+			return fmt.Errorf("unmarshalling NamedExtras %q: %w", r.name, err)
+		}
+	}
+	return nil
+}
+
+// CheckConfigForkOrder returns the first error returned by a registrant.
+func (e namedChainConfigExtras) CheckConfigForkOrder() error {
+	for _, r := range registeredNamedExtras.inOrder() {
+		if err := e.hooks(r).CheckConfigForkOrder(); err != nil {
+			return fmt.Errorf("NamedExtras %q: %w", r.name, err)
+		}
+	}
+	return nil
+}
+
+// CheckConfigCompatible returns the first error returned by a registrant.
+func (e namedChainConfigExtras) CheckConfigCompatible(newcfg *ChainConfig, headNumber *big.Int, headTimestamp uint64) *ConfigCompatError {
+	for _, r := range registeredNamedExtras.inOrder() {
+		if err := e.hooks(r).CheckConfigCompatible(newcfg, headNumber, headTimestamp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Description concatenates the descriptions of all registrants, each of which
+// is terminated by a newline, if not already, so they form separate lines of
+// the [ChainConfig.Description] banner. Empty descriptions are skipped.
+func (e namedChainConfigExtras) Description() string {
+	var b strings.Builder
+	for _, r := range registeredNamedExtras.inOrder() {
+		d := e.hooks(r).Description()
+		if d == "" {
+			continue
+		}
+		b.WriteString(d)
+		if !strings.HasSuffix(d, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// CanCreateContract blocks contract creation if any registrant does, with each
+// receiving the gas remaining after the previous one.
+func (e namedRulesExtras) CanCreateContract(ac *libevm.AddressContext, gas uint64, s libevm.StateReader) (uint64, error) {
+	for _, r := range registeredNamedExtras.inOrder() {
+		var err error
+		gas, err = e.hooks(r).CanCreateContract(ac, gas, s)
+		if err != nil {
+			return gas, err
+		}
+	}
+	return gas, nil
+}
+
+// CanDeployCode blocks code deployment if any registrant does, with each
+// receiving the gas remaining after the previous one.
+func (e namedRulesExtras) CanDeployCode(ac *libevm.AddressContext, code []byte, gas uint64, s libevm.StateReader) (uint64, error) {
+	for _, r := range registeredNamedExtras.inOrder() {
+		var err error
+		gas, err = e.hooks(r).CanDeployCode(ac, code, gas, s)
+		if err != nil {
+			return gas, err
+		}
+	}
+	return gas, nil
+}
+
+// CanExecuteTransaction blocks the transaction if any registrant does.
+func (e namedRulesExtras) CanExecuteTransaction(from common.Address, to *common.Address, s libevm.StateReader) error {
+	for _, r := range registeredNamedExtras.inOrder() {
+		if err := e.hooks(r).CanExecuteTransaction(from, to, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrecompileOverride defers to the registrant that declared the address, if
+// any.
+func (e namedRulesExtras) PrecompileOverride(addr common.Address) (libevm.PrecompiledContract, bool) {
+	r, ok := registeredNamedExtras.precompiles[addr]
+	if !ok {
+		return nil, false
+	}
+	return e.hooks(r).PrecompileOverride(addr)
+}
+
+// ContractAddressOverride defers to the registrant that declared that it
+// derives contract addresses, if any.
+func (e namedRulesExtras) ContractAddressOverride(args *libevm.ContractAddressArgs) (common.Address, bool) {
+	if r := registeredNamedExtras.addressDeriver; r != nil {
+		return e.hooks(r).ContractAddressOverride(args)
+	}
+	return common.Address{}, false
+}
+
+// TransferOverride defers to the registrant that declared the asset, if any.
+func (e namedRulesExtras) TransferOverride(id libevm.AssetID) (libevm.Transferrer, bool) {
+	r, ok := registeredNamedExtras.assets[id]
+	if !ok {
+		return nil, false
+	}
+	return e.hooks(r).TransferOverride(id)
+}
+
+// ActivePrecompiles pipes the addresses through all registrants.
+func (e namedRulesExtras) ActivePrecompiles(active []common.Address) []common.Address {
+	for _, r := range registeredNamedExtras.inOrder() {
+		active = e.hooks(r).ActivePrecompiles(active)
+	}
+	return active
+}
+
+// DistributeFees defers to the registrant that declared that it distributes
+// fees, if any, otherwise it falls back to the default behaviour.
+func (e namedRulesExtras) DistributeFees(db libevm.StateDB, f *libevm.TransactionFees) {
+	if r := registeredNamedExtras.feeDistributor; r != nil {
+		e.hooks(r).DistributeFees(db, f)
+		return
+	}
+	NOOPHooks{}.DistributeFees(db, f)
+}
+
+// IntrinsicGas pipes the intrinsic gas through all registrants.
+func (e namedRulesExtras) IntrinsicGas(msg *libevm.Message, gas uint64) (uint64, error) {
+	for _, r := range registeredNamedExtras.inOrder() {
+		var err error
+		gas, err = e.hooks(r).IntrinsicGas(msg, gas)
+		if err != nil {
+			return 0, fmt.Errorf("NamedExtras %q: %w", r.name, err)
+		}
+	}
+	return gas, nil
+}
+
+// GasRefund defers to the registrant that declared that it refunds gas, if
+// any, otherwise it falls back to the default behaviour.
+func (e namedRulesExtras) GasRefund(gasUsed, refundCounter, defaultQuotient uint64) uint64 {
+	if r := registeredNamedExtras.gasRefunder; r != nil {
+		return e.hooks(r).GasRefund(gasUsed, refundCounter, defaultQuotient)
+	}
+	return NOOPHooks{}.GasRefund(gasUsed, refundCounter, defaultQuotient)
+}
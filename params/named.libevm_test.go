@@ -0,0 +1,428 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package params_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/libevm"
+	"github.com/ethereum/go-ethereum/libevm/ethtest"
+	"github.com/ethereum/go-ethereum/libevm/hookstest"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type allowlistConfig struct {
+	params.NOOPHooks
+	Blocked common.Address `json:"blocked"`
+}
+
+type allowlistRules struct {
+	params.NOOPHooks
+	blocked common.Address
+}
+
+func (r allowlistRules) CanExecuteTransaction(from common.Address, _ *common.Address, _ libevm.StateReader) error {
+	if from == r.blocked {
+		return errors.New("blocked")
+	}
+	return nil
+}
+
+func (allowlistConfig) Description() string { return "Allowlist\n" }
+
+type feeConfig struct {
+	params.NOOPHooks
+	Recipient common.Address `json:"recipient"`
+}
+
+func (feeConfig) Description() string { return "Fees\n" }
+
+func TestRegisterNamedExtras(t *testing.T) {
+	params.TestOnlyClearRegisteredExtras()
+	t.Cleanup(params.TestOnlyClearRegisteredExtras)
+
+	rng := ethtest.NewPseudoRand(42)
+	precompile := rng.Address()
+	asset := libevm.AssetID(rng.Hash())
+	var distributedFees *libevm.TransactionFees
+	stub := &hookstest.Stub{
+		DistributeFeesFn: func(_ libevm.StateDB, f *libevm.TransactionFees) {
+			distributedFees = f
+		},
+		IntrinsicGasFn: func(_ *libevm.Message, gas uint64) (uint64, error) {
+			return gas + 1, nil
+		},
+		GasRefundFn: func(gasUsed, _, _ uint64) uint64 {
+			return gasUsed
+		},
+		PrecompileOverrides: map[common.Address]libevm.PrecompiledContract{
+			precompile: vmPrecompileStub{},
+			// Not declared in [params.NamedExtras.Precompiles] so MUST be
+			// ignored.
+			rng.Address(): vmPrecompileStub{},
+		},
+		TransferOverrides: map[libevm.AssetID]libevm.Transferrer{
+			asset: nil,
+			// Not declared in [params.NamedExtras.Assets] so MUST be ignored.
+			libevm.AssetID(rng.Hash()): nil,
+		},
+	}
+
+	allowlist := params.RegisterNamedExtras("allowlist", params.NamedExtras[allowlistConfig, allowlistRules]{
+		NewRules: func(_ *params.ChainConfig, _ *params.Rules, c allowlistConfig, _ *big.Int, _ bool, _ uint64) allowlistRules {
+			return allowlistRules{blocked: c.Blocked}
+		},
+	})
+	fees := params.RegisterNamedExtras("fees", params.NamedExtras[feeConfig, *hookstest.Stub]{
+		NewRules: func(*params.ChainConfig, *params.Rules, feeConfig, *big.Int, bool, uint64) *hookstest.Stub {
+			return stub
+		},
+		Precompiles:     []common.Address{precompile},
+		Assets:          []libevm.AssetID{asset},
+		DistributesFees: true,
+		RefundsGas:      true,
+	})
+
+	blocked := rng.Address()
+	recipient := rng.Address()
+	input := []byte(`{"chainId":1,"extra":{"allowlist":{"blocked":"` + blocked.Hex() + `"},"fees":{"recipient":"` + recipient.Hex() + `"}}}`)
+
+	cfg := new(params.ChainConfig)
+	require.NoError(t, json.Unmarshal(input, cfg), "json.Unmarshal(..., ChainConfig)")
+
+	t.Run("JSON", func(t *testing.T) {
+		assert.Equal(t, blocked, allowlist.FromChainConfig(cfg).Blocked, "allowlist payload")
+		assert.Equal(t, recipient, fees.FromChainConfig(cfg).Recipient, "fees payload")
+
+		buf, err := json.Marshal(cfg)
+		require.NoError(t, err, "json.Marshal(ChainConfig)")
+		got := new(params.ChainConfig)
+		require.NoError(t, json.Unmarshal(buf, got), "json.Unmarshal(json.Marshal(ChainConfig))")
+		assert.Equal(t, allowlist.FromChainConfig(cfg), allowlist.FromChainConfig(got), "allowlist payload after round trip")
+		assert.Equal(t, fees.FromChainConfig(cfg), fees.FromChainConfig(got), "fees payload after round trip")
+	})
+
+	t.Run("SetOnChainConfig", func(t *testing.T) {
+		c := new(params.ChainConfig)
+		assert.Zero(t, allowlist.FromChainConfig(c), "zero-value payload")
+		allowlist.SetOnChainConfig(c, allowlistConfig{Blocked: blocked})
+		assert.Equal(t, blocked, allowlist.FromChainConfig(c).Blocked, "payload after SetOnChainConfig()")
+		assert.Zero(t, fees.FromChainConfig(c), "other payload unaffected by SetOnChainConfig()")
+
+		fees.PointerFromChainConfig(c).Recipient = recipient
+		assert.Equal(t, recipient, fees.FromChainConfig(c).Recipient, "payload after modification via PointerFromChainConfig()")
+	})
+
+	rules := cfg.Rules(big.NewInt(0), false, 0)
+
+	t.Run("Rules", func(t *testing.T) {
+		assert.Equal(t, blocked, allowlist.FromRules(&rules).blocked, "allowlist Rules payload from NewRules()")
+		assert.Same(t, stub, fees.FromRules(&rules), "fees Rules payload from NewRules()")
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		hooks := rules.Hooks()
+		assert.EqualError(t, hooks.CanExecuteTransaction(blocked, nil, nil), "blocked", "CanExecuteTransaction() rejected by one registrant")
+		assert.NoError(t, hooks.CanExecuteTransaction(rng.Address(), nil, nil), "CanExecuteTransaction() accepted by all registrants")
+
+		for addr := range stub.PrecompileOverrides {
+			_, got := hooks.PrecompileOverride(addr)
+			assert.Equalf(t, addr == precompile, got, "PrecompileOverride(%v) overrides i.f.f. declared", addr)
+		}
+		for id := range stub.TransferOverrides {
+			_, got := hooks.TransferOverride(id)
+			assert.Equalf(t, id == asset, got, "TransferOverride(%#x) overrides i.f.f. declared", id)
+		}
+
+		fees := &libevm.TransactionFees{GasUsed: rng.Uint64()}
+		hooks.DistributeFees(nil, fees)
+		assert.Same(t, fees, distributedFees, "DistributeFees() called on declaring registrant")
+
+		gas, err := hooks.IntrinsicGas(nil, 100)
+		require.NoError(t, err, "IntrinsicGas()")
+		assert.Equal(t, uint64(101), gas, "IntrinsicGas() piped through all registrants")
+		assert.Equal(t, uint64(42), hooks.GasRefund(42, 0, params.RefundQuotient), "GasRefund() called on declaring registrant")
+
+		assert.True(t, strings.HasSuffix(cfg.Description(), "Allowlist\nFees\n"), "ChainConfig.Description() ends with registrants' in order")
+	})
+}
+
+func TestRegisterNamedExtrasPanics(t *testing.T) {
+	rng := ethtest.NewPseudoRand(1)
+	addr := rng.Address()
+
+	tests := []struct {
+		name     string
+		register func()
+	}{
+		{
+			name: "empty name",
+			register: func() {
+				params.RegisterNamedExtras("", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{})
+			},
+		},
+		{
+			name: "duplicate name",
+			register: func() {
+				params.RegisterNamedExtras("x", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{})
+				params.RegisterNamedExtras("x", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{})
+			},
+		},
+		{
+			name: "conflicting precompile",
+			register: func() {
+				params.RegisterNamedExtras("x", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					Precompiles: []common.Address{addr},
+				})
+				params.RegisterNamedExtras("y", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					Precompiles: []common.Address{addr},
+				})
+			},
+		},
+		{
+			name: "conflicting asset",
+			register: func() {
+				id := libevm.AssetID(rng.Hash())
+				params.RegisterNamedExtras("x", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					Assets: []libevm.AssetID{id},
+				})
+				params.RegisterNamedExtras("y", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					Assets: []libevm.AssetID{id},
+				})
+			},
+		},
+		{
+			name: "conflicting fee distribution",
+			register: func() {
+				params.RegisterNamedExtras("x", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					DistributesFees: true,
+				})
+				params.RegisterNamedExtras("y", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					DistributesFees: true,
+				})
+			},
+		},
+		{
+			name: "conflicting gas refund",
+			register: func() {
+				params.RegisterNamedExtras("x", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					RefundsGas: true,
+				})
+				params.RegisterNamedExtras("y", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					RefundsGas: true,
+				})
+			},
+		},
+		{
+			name: "conflicting contract-address derivation",
+			register: func() {
+				params.RegisterNamedExtras("x", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					DerivesContractAddresses: true,
+				})
+				params.RegisterNamedExtras("y", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{
+					DerivesContractAddresses: true,
+				})
+			},
+		},
+		{
+			name: "after RegisterExtras",
+			register: func() {
+				params.RegisterExtras(params.Extras[params.NOOPHooks, params.NOOPHooks]{})
+				params.RegisterNamedExtras("x", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{})
+			},
+		},
+		{
+			name: "before RegisterExtras",
+			register: func() {
+				params.RegisterNamedExtras("x", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{})
+				params.RegisterExtras(params.Extras[params.NOOPHooks, params.NOOPHooks]{})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params.TestOnlyClearRegisteredExtras()
+			t.Cleanup(params.TestOnlyClearRegisteredExtras)
+			assert.Panics(t, tt.register)
+		})
+	}
+}
+
+type vmPrecompileStub struct{}
+
+func (vmPrecompileStub) RequiredGas([]byte) uint64  { return 0 }
+func (vmPrecompileStub) Run([]byte) ([]byte, error) { return nil, nil }
+
+func TestNamedExtrasConcurrentReads(t *testing.T) {
+	params.TestOnlyClearRegisteredExtras()
+	t.Cleanup(params.TestOnlyClearRegisteredExtras)
+
+	allowlist := params.RegisterNamedExtras("allowlist", params.NamedExtras[allowlistConfig, allowlistRules]{})
+	fees := params.RegisterNamedExtras("fees", params.NamedExtras[*feeConfig, params.NOOPHooks]{})
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1)} // no payloads set
+	fromJSON := new(params.ChainConfig)
+	require.NoError(t, json.Unmarshal([]byte(`{"extra":{"fees":{}}}`), fromJSON), "json.Unmarshal(..., ChainConfig)")
+
+	for _, c := range []*params.ChainConfig{config, fromJSON} {
+		rules := c.Rules(big.NewInt(0), false, 0)
+
+		// Under the race detector, any lazy storage of payloads in the shared
+		// ChainConfig or Rules will be reported.
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.Rules(big.NewInt(0), false, 0)
+				_ = allowlist.FromChainConfig(c)
+				_ = fees.FromChainConfig(c)
+				_ = allowlist.FromRules(&rules)
+				_ = fees.FromRules(&rules)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestMayOverrideContractAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func()
+		want     bool
+	}{
+		{
+			name:     "no_extras",
+			register: func() {},
+			want:     false,
+		},
+		{
+			name: "RegisterExtras",
+			register: func() {
+				params.RegisterExtras(params.Extras[params.NOOPHooks, params.NOOPHooks]{})
+			},
+			want: true,
+		},
+		{
+			name: "named_without_address_deriver",
+			register: func() {
+				params.RegisterNamedExtras("allowlist", params.NamedExtras[allowlistConfig, allowlistRules]{})
+			},
+			want: false,
+		},
+		{
+			name: "named_with_address_deriver",
+			register: func() {
+				params.RegisterNamedExtras("allowlist", params.NamedExtras[allowlistConfig, allowlistRules]{})
+				params.RegisterNamedExtras("fees", params.NamedExtras[feeConfig, params.NOOPHooks]{
+					DerivesContractAddresses: true,
+				})
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params.TestOnlyClearRegisteredExtras()
+			t.Cleanup(params.TestOnlyClearRegisteredExtras)
+
+			tt.register()
+			assert.Equal(t, tt.want, params.MayOverrideContractAddresses())
+		})
+	}
+}
+
+func TestNamedSetOnMemoizedRules(t *testing.T) {
+	params.TestOnlyClearRegisteredExtras()
+	t.Cleanup(params.TestOnlyClearRegisteredExtras)
+
+	rng := ethtest.NewPseudoRand(7)
+	blocked := rng.Address()
+	allowlist := params.RegisterNamedExtras("allowlist", params.NamedExtras[allowlistConfig, allowlistRules]{
+		NewRules: func(_ *params.ChainConfig, _ *params.Rules, c allowlistConfig, _ *big.Int, _ bool, _ uint64) allowlistRules {
+			return allowlistRules{blocked: c.Blocked}
+		},
+		MemoizeRules: true,
+	})
+	params.RegisterNamedExtras("fees", params.NamedExtras[feeConfig, params.NOOPHooks]{
+		MemoizeRules: true,
+	})
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1)}
+	allowlist.SetOnChainConfig(config, allowlistConfig{Blocked: blocked})
+	rules := func() params.Rules {
+		return config.Rules(big.NewInt(0), false, 0)
+	}
+	_ = rules() // memoize
+
+	// Under the race detector, any modification of the payloads shared by
+	// memoized copies of the Rules will be reported.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := rules()
+			_ = allowlist.FromRules(&r)
+			_ = r.Hooks().CanExecuteTransaction(blocked, nil, nil)
+		}()
+	}
+	wg.Add(1)
+	modified := rules()
+	go func() {
+		defer wg.Done()
+		allowlist.SetOnRules(&modified, allowlistRules{})
+	}()
+	wg.Wait()
+
+	assert.Zero(t, allowlist.FromRules(&modified), "payload after SetOnRules()")
+	r := rules()
+	assert.Equal(t, blocked, allowlist.FromRules(&r).blocked, "memoized payload unaffected by SetOnRules() on a copy")
+}
+
+type alphaConfig struct{ params.NOOPHooks }
+
+func (alphaConfig) Description() string { return "Alpha: one" }
+
+type betaConfig struct{ params.NOOPHooks }
+
+func (betaConfig) Description() string { return "Beta: two" }
+
+func TestNamedExtrasDescription(t *testing.T) {
+	params.TestOnlyClearRegisteredExtras()
+	t.Cleanup(params.TestOnlyClearRegisteredExtras)
+
+	params.RegisterNamedExtras("alpha", params.NamedExtras[alphaConfig, params.NOOPHooks]{})
+	params.RegisterNamedExtras("silent", params.NamedExtras[params.NOOPHooks, params.NOOPHooks]{})
+	params.RegisterNamedExtras("beta", params.NamedExtras[betaConfig, params.NOOPHooks]{})
+	params.RegisterNamedExtras("allowlist", params.NamedExtras[allowlistConfig, allowlistRules]{})
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1)}
+	got := config.Description()
+	assert.Truef(t, strings.HasSuffix(got, "\nAlpha: one\nBeta: two\nAllowlist\n"), "%T.Description() ends with registrants' descriptions on separate lines; got:\n%s", config, got)
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+)
+
+// rulesCacheSize is the maximum number of memoized [Rules]; see
+// [Extras.MemoizeRules].
+const rulesCacheSize = 256
+
+// rulesKey identifies the arguments to, and receiver of, a call to
+// [ChainConfig.Rules]. Only block numbers that fit in a uint64 are memoized.
+type rulesKey struct {
+	config    *ChainConfig
+	blockNum  uint64
+	isMerge   bool
+	timestamp uint64
+}
+
+func newRulesKey(c *ChainConfig, blockNum *big.Int, isMerge bool, timestamp uint64) (rulesKey, bool) {
+	if blockNum == nil || !blockNum.IsUint64() {
+		return rulesKey{}, false
+	}
+	return rulesKey{c, blockNum.Uint64(), isMerge, timestamp}, true
+}
+
+// rulesCache returns the cache of memoized [Rules], which is nil if
+// memoization isn't enabled.
+func rulesCache() *lru.Cache[rulesKey, Rules] {
+	if registeredExtras == nil {
+		return nil
+	}
+	return registeredExtras.rulesCache
+}
+
+// memoizedRules returns the [Rules] memoized by [ChainConfig.memoizeRules] for
+// the same receiver and arguments, if any.
+func (c *ChainConfig) memoizedRules(blockNum *big.Int, isMerge bool, timestamp uint64) (Rules, bool) {
+	cache := rulesCache()
+	if cache == nil {
+		return Rules{}, false
+	}
+	key, ok := newRulesKey(c, blockNum, isMerge, timestamp)
+	if !ok {
+		return Rules{}, false
+	}
+	r, ok := cache.Get(key)
+	if !ok {
+		return Rules{}, false
+	}
+	// Upstream geth always returns a fresh ChainID so we do the same, but the
+	// extra payload is shared.
+	r.ChainID = new(big.Int).Set(r.ChainID)
+	return r, true
+}
+
+// memoizeRules stores the [Rules] returned by [ChainConfig.Rules] if
+// memoization is enabled.
+func (c *ChainConfig) memoizeRules(r *Rules, blockNum *big.Int, isMerge bool, timestamp uint64) {
+	cache := rulesCache()
+	if cache == nil {
+		return
+	}
+	key, ok := newRulesKey(c, blockNum, isMerge, timestamp)
+	if !ok {
+		return
+	}
+	memo := *r
+	memo.ChainID = new(big.Int).Set(r.ChainID)
+	cache.Add(key, memo)
+}
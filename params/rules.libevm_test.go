@@ -0,0 +1,192 @@
+// Copyright 2024 the libevm authors.
+//
+// The libevm additions to go-ethereum are free software: you can redistribute
+// them and/or modify them under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The libevm additions are distributed in the hope that they will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package params_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type memoConfig struct {
+	params.NOOPHooks
+	Addresses []common.Address `json:"addresses"`
+}
+
+type memoRules struct {
+	params.NOOPHooks
+	allowed map[common.Address]bool
+}
+
+// newMemoRules is representative of a NewRules function that reads the
+// ChainConfig extra and allocates.
+func newMemoRules(calls *int) func(*params.ChainConfig, *params.Rules, *memoConfig, *big.Int, bool, uint64) *memoRules {
+	return func(_ *params.ChainConfig, _ *params.Rules, c *memoConfig, _ *big.Int, _ bool, _ uint64) *memoRules {
+		*calls++
+		r := &memoRules{
+			allowed: make(map[common.Address]bool),
+		}
+		if c == nil {
+			return r
+		}
+		for _, a := range c.Addresses {
+			r.allowed[a] = true
+		}
+		return r
+	}
+}
+
+func TestMemoizeRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		register    func(calls *int) params.ExtraPayloads[*memoConfig, *memoRules]
+		wantMemoize bool
+	}{
+		{
+			name: "not memoized",
+			register: func(calls *int) params.ExtraPayloads[*memoConfig, *memoRules] {
+				return params.RegisterExtras(params.Extras[*memoConfig, *memoRules]{
+					NewRules: newMemoRules(calls),
+				})
+			},
+			wantMemoize: false,
+		},
+		{
+			name: "memoized",
+			register: func(calls *int) params.ExtraPayloads[*memoConfig, *memoRules] {
+				return params.RegisterExtras(params.Extras[*memoConfig, *memoRules]{
+					NewRules:     newMemoRules(calls),
+					MemoizeRules: true,
+				})
+			},
+			wantMemoize: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params.TestOnlyClearRegisteredExtras()
+			t.Cleanup(params.TestOnlyClearRegisteredExtras)
+
+			var calls int
+			tt.register(&calls)
+
+			config := &params.ChainConfig{ChainID: big.NewInt(1)}
+			num := big.NewInt(42)
+			const timestamp = 1234
+
+			first := config.Rules(num, false, timestamp)
+			require.Equal(t, 1, calls, "NewRules() calls after first call to Rules()")
+
+			second := config.Rules(num, false, timestamp)
+			assert.Equal(t, first, second, "Rules() with identical arguments")
+			assert.NotSame(t, first.ChainID, second.ChainID, "Rules().ChainID MUST NOT be shared")
+
+			want := 2
+			if tt.wantMemoize {
+				want = 1
+			}
+			assert.Equal(t, want, calls, "NewRules() calls after Rules() with identical arguments")
+
+			// Any differences in the receiver or arguments MUST result in new
+			// Rules.
+			cp := *config
+			for _, fn := range []func(){
+				func() { config.Rules(big.NewInt(43), false, timestamp) },
+				func() { config.Rules(num, true, timestamp) },
+				func() { config.Rules(num, false, timestamp+1) },
+				func() { cp.Rules(num, false, timestamp) },
+			} {
+				before := calls
+				fn()
+				assert.Equal(t, before+1, calls, "NewRules() calls after Rules() with different receiver or arguments")
+			}
+
+			for i := 0; i < 2; i++ {
+				before := calls
+				config.Rules(nil, false, 0)
+				assert.Equal(t, before+1, calls, "NewRules() calls after Rules() with nil block number")
+			}
+		})
+	}
+}
+
+func TestNamedExtrasMemoizeRules(t *testing.T) {
+	for _, memoizeB := range []bool{true, false} {
+		t.Run(fmt.Sprintf("second registrant memoizes=%t", memoizeB), func(t *testing.T) {
+			params.TestOnlyClearRegisteredExtras()
+			t.Cleanup(params.TestOnlyClearRegisteredExtras)
+
+			var callsA, callsB int
+			params.RegisterNamedExtras("a", params.NamedExtras[*memoConfig, *memoRules]{
+				NewRules:     newMemoRules(&callsA),
+				MemoizeRules: true,
+			})
+			params.RegisterNamedExtras("b", params.NamedExtras[*memoConfig, *memoRules]{
+				NewRules:     newMemoRules(&callsB),
+				MemoizeRules: memoizeB,
+			})
+
+			config := &params.ChainConfig{ChainID: big.NewInt(1)}
+			for i := 0; i < 2; i++ {
+				config.Rules(big.NewInt(1), false, 0)
+			}
+
+			want := 2
+			if memoizeB {
+				want = 1
+			}
+			assert.Equal(t, want, callsA, "NewRules() calls of first registrant")
+			assert.Equal(t, want, callsB, "NewRules() calls of second registrant")
+		})
+	}
+}
+
+func BenchmarkRules(b *testing.B) {
+	addrs := make([]common.Address, 16)
+	for i := range addrs {
+		addrs[i] = common.BigToAddress(big.NewInt(int64(i)))
+	}
+
+	for _, memoize := range []bool{false, true} {
+		b.Run(fmt.Sprintf("memoize=%t", memoize), func(b *testing.B) {
+			params.TestOnlyClearRegisteredExtras()
+			b.Cleanup(params.TestOnlyClearRegisteredExtras)
+
+			var calls int
+			payloads := params.RegisterExtras(params.Extras[*memoConfig, *memoRules]{
+				NewRules:     newMemoRules(&calls),
+				MemoizeRules: memoize,
+			})
+			config := &params.ChainConfig{ChainID: big.NewInt(1)}
+			payloads.SetOnChainConfig(config, &memoConfig{Addresses: addrs})
+
+			num := big.NewInt(1)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				config.Rules(num, false, 0)
+			}
+		})
+	}
+}